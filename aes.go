@@ -10,6 +10,7 @@ package analytics
 
 import (
 	"bytes"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"encoding/base64"
@@ -196,6 +197,11 @@ func AESDecrypt(key []byte, ciphertextBase64 string) ([]byte, error) {
 //   - 响应数据（如果服务器返回加密数据，已自动解密）
 //   - 错误信息
 func (c *AESClient) PostEncrypted(path string, data interface{}) ([]byte, error) {
+	return c.PostEncryptedContext(context.Background(), path, data)
+}
+
+// PostEncryptedContext 与 PostEncrypted 相同，ctx 结束时取消请求
+func (c *AESClient) PostEncryptedContext(ctx context.Context, path string, data interface{}) ([]byte, error) {
 	// 序列化数据
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -220,7 +226,7 @@ func (c *AESClient) PostEncrypted(path string, data interface{}) ([]byte, error)
 
 	// 创建请求
 	url := c.BaseURL + path
-	req, err := http.NewRequest("POST", url, bytes.NewReader(reqBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("create request error: %w", err)
 	}
@@ -275,13 +281,18 @@ func (c *AESClient) PostEncrypted(path string, data interface{}) ([]byte, error)
 //   - 响应数据
 //   - 错误信息
 func (c *AESClient) PostPlain(path string, data interface{}) ([]byte, error) {
+	return c.PostPlainContext(context.Background(), path, data)
+}
+
+// PostPlainContext 与 PostPlain 相同，ctx 结束时取消请求
+func (c *AESClient) PostPlainContext(ctx context.Context, path string, data interface{}) ([]byte, error) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return nil, fmt.Errorf("marshal data error: %w", err)
 	}
 
 	url := c.BaseURL + path
-	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("create request error: %w", err)
 	}
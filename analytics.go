@@ -15,14 +15,21 @@ package analytics
 
 import (
 	"bytes"
+	"context"
+	"crypto"
 	"crypto/sha256"
+	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log/slog"
 	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -33,6 +40,11 @@ import (
 type EncryptionConfig struct {
 	Enabled   bool
 	SecretKey string
+	Keyring   *Keyring // 设置后优先于 SecretKey，使用活动密钥加密并携带 kid
+	
+	// PublicKey 设置后使用公钥信封加密，客户端无需持有解密密钥
+	PublicKey   crypto.PublicKey
+	PublicKeyID string
 }
 
 // Client 分析客户端
@@ -50,9 +62,43 @@ type Client struct {
 	bufferSize     int
 	debug          bool
 	logger         Logger
+	slogger        *slog.Logger
 	sessionID      string
 	sessionStarted time.Time
 	encryption     *EncryptionConfig // 加密配置
+	signingKey     []byte            // 请求签名密钥
+	tlsConfig      *tls.Config       // 自定义 TLS 配置
+	pinnedSPKI     []string          // 固定的证书公钥摘要
+	clientCerts    []tls.Certificate // 双向 TLS 客户端证书
+	extractors     []ContextExtractor // context 属性提取器
+	observers      []SendObserver     // 发送观察者
+	ctx            context.Context    // 客户端生命周期 context，关闭时取消
+	cancel         context.CancelFunc
+	closeOnce      sync.Once
+	repanic        bool          // 上报崩溃后是否重新 panic
+	crashTimeout   time.Duration // 崩溃事件发送超时
+	errorStack     bool                // TrackError 是否附带堆栈
+	errorLimiter   *fingerprintLimiter // 错误事件按指纹限流
+	consent        consentManager      // 用户授权状态
+	identityMu     sync.RWMutex        // 保护 deviceID、userID、sessionID、sessionStarted
+	identityFile   string              // 轮换后设备ID的持久化文件
+	deviceIDSet    bool                // 是否通过 WithDeviceID 显式指定
+	generation     atomic.Uint64       // 身份代数，删除数据后递增
+	sampler        *sampler            // 事件采样，nil 表示不采样
+	limiter        *rateLimiter        // 限流与配额，nil 表示不限流
+	breaker        *circuitBreaker     // 熔断器，nil 表示不启用
+	heldDropped    int                 // 关闭时未能补发而丢弃的暂存事件数
+	fallbacks      []string            // 备用端点
+	healthPath     string              // 健康检查路径
+	healthInterval time.Duration       // 健康检查间隔
+	endpoints      *endpointSet        // 主端点与备用端点
+	senders        int                 // 并发发送的 goroutine 数
+	sessionOrdering bool               // 同一会话的事件按顺序发送
+	eventCatalog   bool                // 泛型 Track 只接受事件目录中定义的事件
+	batching       batching            // 批次字节上限与自适应大小
+	schema         *schemaValidator    // 跟踪计划校验，nil 表示不校验
+	propertyLimits PropertyLimits      // 属性规范化的大小限制
+	configErr      error               // 创建客户端时的配置错误，非 nil 时所有请求都会失败
 }
 
 // Event 表示一个分析事件
@@ -66,6 +112,18 @@ type Event struct {
 	Action   string  `json:"action,omitempty"`
 	Label    string  `json:"label,omitempty"`
 	Value    float64 `json:"value,omitempty"`
+	
+	// Consent 事件所属的授权类别，为空时视为 ConsentUsage
+	Consent string `json:"consent,omitempty"`
+	
+	// SampleRate 事件被采样时的采样率，服务端可据此还原计数
+	SampleRate float64 `json:"sample_rate,omitempty"`
+	
+	// generation 入队时的身份代数加一，0 表示未经过队列
+	generation uint64
+	
+	// pooled 是否来自对象池，发送完成后放回
+	pooled bool
 }
 
 // Logger 日志接口
@@ -80,6 +138,7 @@ type ClientOption func(*Client)
 func WithDeviceID(deviceID string) ClientOption {
 	return func(c *Client) {
 		c.deviceID = deviceID
+		c.deviceIDSet = true
 	}
 }
 
@@ -162,6 +221,7 @@ func WithEncryption(secretKey string) ClientOption {
 //	// 发送事件
 //	client.Track("button_click", map[string]interface{}{"button": "submit"})
 func NewClient(serverURL, productName string, opts ...ClientOption) *Client {
+	ctx, cancel := context.WithCancel(context.Background())
 	client := &Client{
 		serverURL:     serverURL,
 		productName:   productName,
@@ -174,12 +234,32 @@ func NewClient(serverURL, productName string, opts ...ClientOption) *Client {
 		quit:          make(chan struct{}),
 		sessionID:     uuid.New().String(),
 		sessionStarted: time.Now(),
+		ctx:           ctx,
+		cancel:        cancel,
+		repanic:       true,
+		crashTimeout:  5 * time.Second,
+		errorLimiter:  newFingerprintLimiter(10, time.Minute),
 	}
 	
 	// 应用配置选项
 	for _, opt := range opts {
 		opt(client)
 	}
+	if err := client.applyTLSOptions(); err != nil {
+		// 无法保证 TLS 安全要求时拒绝发送任何请求，而不是退回到未加固的连接
+		client.configErr = err
+		if client.logger != nil {
+			client.logger.Printf("[Analytics] Invalid configuration, requests are disabled: %v", err)
+		}
+		client.logAttrs(slog.LevelError, "analytics: invalid configuration", slog.Any("error", err))
+	}
+	client.initEndpoints()
+	client.initBatching()
+	if client.breaker != nil {
+		client.breaker.maxHeld = client.bufferSize
+	}
+	client.consent.load()
+	client.loadIdentity()
 	
 	// 创建事件通道
 	client.events = make(chan *Event, client.bufferSize)
@@ -187,6 +267,14 @@ func NewClient(serverURL, productName string, opts ...ClientOption) *Client {
 	// 启动后台处理
 	client.wg.Add(1)
 	go client.processEvents()
+	if client.limiter != nil {
+		client.wg.Add(1)
+		go client.runRateLimitReporter()
+	}
+	if client.endpoints.healthInterval > 0 {
+		client.wg.Add(1)
+		go client.runHealthChecks()
+	}
 	
 	return client
 }
@@ -197,20 +285,11 @@ func NewClient(serverURL, productName string, opts ...ClientOption) *Client {
 //	    "button_name": "login",
 //	})
 func (c *Client) Track(eventName string, properties map[string]interface{}) {
-	event := &Event{
-		Name:       eventName,
-		Timestamp:  time.Now().Unix(),
-		Properties: properties,
-	}
-	
-	select {
-	case c.events <- event:
-		// 成功加入队列
-	default:
-		if c.debug && c.logger != nil {
-			c.logger.Printf("[Analytics] Event buffer full, dropping event: %s", eventName)
-		}
-	}
+	e := newEvent()
+	e.Name = eventName
+	e.Timestamp = time.Now().Unix()
+	e.Properties = properties
+	c.enqueue(e)
 }
 
 // TrackEvent 发送分类事件（Google Analytics 风格）
@@ -226,27 +305,20 @@ func (c *Client) Track(eventName string, properties map[string]interface{}) {
 //	    "value": 1,
 //	})
 func (c *Client) TrackEvent(category, action, label string, value float64) {
-	event := &Event{
-		Name:      action,
-		Timestamp: time.Now().Unix(),
-		Category:  category,
-		Action:    action,
-		Label:     label,
-		Value:     value,
-	}
-	
-	select {
-	case c.events <- event:
-		// 成功加入队列
-	default:
-		if c.debug && c.logger != nil {
-			c.logger.Printf("[Analytics] Event buffer full, dropping event: %s/%s", category, action)
-		}
-	}
+	e := newEvent()
+	e.Name = action
+	e.Timestamp = time.Now().Unix()
+	e.Category = category
+	e.Action = action
+	e.Label = label
+	e.Value = value
+	c.enqueue(e)
 }
 
 // TrackSync 同步发送事件（阻塞直到发送完成）
 //
+// 事件同样按跟踪计划校验，被丢弃时不发送，SchemaReject 模式下返回 *SchemaError。
+//
 // Deprecated: Use Track followed by Flush for better control.
 // Migration example:
 //
@@ -259,24 +331,69 @@ func (c *Client) TrackSync(eventName string, properties map[string]interface{})
 		Timestamp:  time.Now().Unix(),
 		Properties: properties,
 	}
+	c.normalizeEvent(event)
+	if drop, err := c.checkSchema(event); drop {
+		return err
+	}
 	
-	return c.sendEvents([]*Event{event})
+	return c.sendEvents(c.ctx, []*Event{event})
 }
 
 // TrackBatch 批量发送事件
 func (c *Client) TrackBatch(events []Event) {
 	for _, event := range events {
-		evt := event
-		evt.Timestamp = time.Now().Unix()
-		
-		select {
-		case c.events <- &evt:
-			// 成功加入队列
-		default:
-			if c.debug && c.logger != nil {
-				c.logger.Printf("[Analytics] Event buffer full, dropping event: %s", event.Name)
-			}
+		e := newEvent()
+		*e = event
+		e.Timestamp = time.Now().Unix()
+		e.generation = 0
+		e.pooled = true
+		c.enqueue(e)
+	}
+}
+
+// enqueue 将事件加入发送队列
+//
+// 属性在入队时被规范化为可编码的 JSON 值，之后再按跟踪计划校验。
+// 未被授权或未被采样的事件直接丢弃并返回 nil；违反跟踪计划、被限流或缓冲区满时
+// 丢弃并返回原因（SchemaDrop 模式下违规不返回错误）。
+// 事件的所有权转移给客户端，调用方之后不能再访问它。
+func (c *Client) enqueue(event *Event) error {
+	if !c.consent.allows(event.Consent) || !c.sample(event) {
+		releaseEvent(event)
+		return nil
+	}
+	c.normalizeEvent(event)
+	if drop, err := c.checkSchema(event); drop {
+		releaseEvent(event)
+		return err
+	}
+	if c.limiter != nil && !c.limiter.allow(event.Name, time.Now()) {
+		releaseEvent(event)
+		return ErrRateLimited
+	}
+	if !c.push(event) {
+		return ErrBufferFull
+	}
+	return nil
+}
+
+// push 将事件放入发送通道，缓冲区满时丢弃并返回 false
+func (c *Client) push(event *Event) bool {
+	event.generation = c.generation.Load() + 1
+	select {
+	case c.events <- event:
+		// 成功加入队列
+		return true
+	default:
+		if c.debug && c.logger != nil {
+			c.logger.Printf("[Analytics] Event buffer full, dropping event: %s", event.Name)
+		}
+		c.logAttrs(slog.LevelWarn, "analytics: event buffer full, dropping event", slog.String("event", event.Name))
+		if c.limiter != nil {
+			c.limiter.recordBufferDrop()
 		}
+		releaseEvent(event)
+		return false
 	}
 }
 
@@ -301,9 +418,7 @@ func (c *Client) Flush() {
 
 // Close 关闭客户端，确保所有事件发送完成
 func (c *Client) Close() error {
-	close(c.quit)
-	c.wg.Wait()
-	return nil
+	return c.Shutdown(context.Background())
 }
 
 // processEvents 后台处理事件
@@ -313,126 +428,256 @@ func (c *Client) processEvents() {
 	ticker := time.NewTicker(c.flushInterval)
 	defer ticker.Stop()
 	
-	batch := make([]*Event, 0, c.batchSize)
+	send, stop := c.startSenders()
+	
+	// 每个组批通道一个批次，按会话排序时同一会话总是进入同一通道
+	batches := make([][]*Event, c.lanes())
+	sizes := make([]int, len(batches))
+	emit := func(lane int) {
+		send(lane, batches[lane])
+		batches[lane] = make([]*Event, 0, c.BatchSize())
+		sizes[lane] = 0
+	}
+	add := func(event *Event) {
+		lane := c.laneOf(event)
+		if maxBytes := int(c.batching.maxBytes.Load()); maxBytes > 0 {
+			size := eventSize(event)
+			if len(batches[lane]) > 0 && sizes[lane]+size > maxBytes {
+				emit(lane)
+			}
+			sizes[lane] += size
+		}
+		batches[lane] = append(batches[lane], event)
+		if len(batches[lane]) >= c.BatchSize() {
+			emit(lane)
+		}
+	}
+	flush := func() {
+		for lane, batch := range batches {
+			if len(batch) > 0 {
+				emit(lane)
+			}
+		}
+	}
 	
 	for {
 		select {
 		case <-c.quit:
-			// 发送剩余事件
-			if len(batch) > 0 {
-				c.sendEvents(batch)
-			}
-			// 清空通道中的剩余事件
+			// 清空通道中的剩余事件并发送
 			for len(c.events) > 0 {
-				event := <-c.events
-				batch = append(batch, event)
-				if len(batch) >= c.batchSize {
-					c.sendEvents(batch)
-					batch = make([]*Event, 0, c.batchSize)
-				}
-			}
-			if len(batch) > 0 {
-				c.sendEvents(batch)
+				add(<-c.events)
 			}
+			flush()
+			stop()
+			c.retryHeld(c.ctx)
+			c.heldDropped = c.dropHeld()
 			return
 			
 		case event := <-c.events:
-			batch = append(batch, event)
-			if len(batch) >= c.batchSize {
-				c.sendEvents(batch)
-				batch = make([]*Event, 0, c.batchSize)
-			}
+			add(event)
 			
 		case <-ticker.C:
-			if len(batch) > 0 {
-				c.sendEvents(batch)
-				batch = make([]*Event, 0, c.batchSize)
-			}
+			flush()
+			c.retryHeld(c.ctx)
 		}
 	}
 }
 
 // sendEvents 发送事件到服务器
-func (c *Client) sendEvents(events []*Event) error {
+func (c *Client) sendEvents(ctx context.Context, events []*Event) error {
+	return c.deliver(ctx, events, false)
+}
+
+// deliver 发送一批事件；retry 表示事件取自熔断器的暂存队列，
+// 失败时放回队首而不是队尾，保持补发顺序
+func (c *Client) deliver(ctx context.Context, events []*Event, retry bool) error {
+	// 发送前再次检查授权，已入队的事件可能在此期间被撤销授权或已被删除。
+	// 与身份在同一把锁内读取：RequestDeletion 在该锁内轮换身份并使旧事件失效，
+	// 删除前入队的事件不会以新身份发送
+	c.identityMu.RLock()
+	events = c.filterSendable(events)
+	payload := batchPayload{
+		Product:   c.productName,
+		DeviceID:  c.deviceID,
+		UserID:    c.userID,
+		SessionID: c.sessionID,
+		Events:    events,
+	}
+	c.identityMu.RUnlock()
 	if len(events) == 0 {
 		return nil
 	}
 	
-	// 构建请求体
-	payload := map[string]interface{}{
-		"product":    c.productName,
-		"device_id":  c.deviceID,
-		"user_id":    c.userID,
-		"session_id": c.sessionID,
-		"events":     events,
+	// 熔断期间直接短路，事件暂存待恢复后补发
+	if c.breaker != nil {
+		allowed, notify := c.breaker.allow(time.Now())
+		if notify != nil {
+			notify()
+		}
+		if !allowed {
+			c.breaker.hold(events, retry)
+			return newNetworkError("POST", c.ActiveEndpoint()+"/api/events/batch", 0, ErrCircuitOpen, true)
+		}
 	}
 	
-	data, err := json.Marshal(payload)
-	if err != nil {
+	// 构建请求体：流式编码到池化的缓冲区
+	buf := getPayloadBuffer()
+	defer buf.release()
+	
+	// 无法编码的事件被单独丢弃，不影响同批次的其他事件
+	var err error
+	var bad []*Event
+	buf.data, bad, err = appendBatch(buf.data, &payload)
+	if len(bad) > 0 {
 		if c.debug && c.logger != nil {
-			c.logger.Printf("[Analytics] Failed to marshal events: %v", err)
+			c.logger.Printf("[Analytics] Dropped %d events that failed to marshal: %v", len(bad), err)
+		}
+		c.logAttrs(slog.LevelError, "analytics: marshal events failed", slog.Int("batch_size", len(events)), slog.Int("dropped", len(bad)), slog.Any("error", err))
+		releaseEvents(bad)
+		events = payload.Events
+		if len(events) == 0 {
+			c.recordSend(err)
+			return newClientError("sendEvents", fmt.Errorf("%w: %v", ErrMarshalFailed, err))
 		}
-		return newClientError("sendEvents", fmt.Errorf("%w: %v", ErrMarshalFailed, err))
 	}
 	
 	// 如果启用了加密，加密数据
 	var requestBody []byte
-	var contentType string
+	var bodyBuf *payloadBuffer
+	contentType := "application/json"
 	
 	if c.encryption != nil && c.encryption.Enabled {
-		// 使用 AES 加密
-		encrypted, err := AESEncrypt([]byte(c.encryption.SecretKey), data)
+		requestBody, err = c.encryptPayload(buf.data)
 		if err != nil {
 			if c.debug && c.logger != nil {
 				c.logger.Printf("[Analytics] Failed to encrypt events: %v", err)
 			}
-			return newClientError("sendEvents", fmt.Errorf("%w: %v", ErrEncryptionFailed, err))
+			c.logAttrs(slog.LevelError, "analytics: encrypt events failed", slog.Int("batch_size", len(events)), slog.Any("error", err))
+			c.recordSend(err)
+			releaseEvents(events)
+			return newClientError("sendEvents", err)
 		}
 		
-		// 构建加密请求体
-		encryptedPayload := map[string]string{
-			"data": encrypted,
-		}
-		requestBody, err = json.Marshal(encryptedPayload)
-		if err != nil {
-			return newClientError("sendEvents", fmt.Errorf("%w: %v", ErrMarshalFailed, err))
-		}
-		contentType = "application/json"
-		
 		if c.debug && c.logger != nil {
 			c.logger.Printf("[Analytics] Events encrypted, sending %d bytes", len(requestBody))
 		}
 	} else {
-		// 不加密，直接发送
-		requestBody = data
-		contentType = "application/json"
+		// 不加密，直接发送缓冲区
+		requestBody = buf.data
+		bodyBuf = buf
 	}
 	
 	// 发送请求
-	url := fmt.Sprintf("%s/api/events/batch", c.serverURL)
-	resp, err := c.httpClient.Post(url, contentType, bytes.NewReader(requestBody))
+	start := time.Now()
+	resp, err := c.post(ctx, "/api/events/batch", contentType, requestBody, bodyBuf, len(events))
+	c.observeBatch(time.Since(start), err)
+	
+	// 先记录结果：413 属于 4xx，会关闭半开的熔断器；拆分后的两半各自记录
+	c.recordSend(err)
+	
+	// 413：拆分批次后分别重发
+	if err != nil && isTooLarge(err) && len(events) > 1 {
+		first, second := c.splitOversized(events, len(requestBody))
+		return errors.Join(c.deliver(ctx, first, retry), c.deliver(ctx, second, retry))
+	}
+	if c.breaker != nil && err != nil && isRetryableError(err) {
+		// 暂存待补发，事件不能放回对象池
+		c.breaker.hold(events, retry)
+		return err
+	}
+	releaseEvents(events)
 	if err != nil {
 		if c.debug && c.logger != nil {
 			c.logger.Printf("[Analytics] Failed to send events: %v", err)
 		}
-		return newNetworkError("POST", url, 0, fmt.Errorf("%w: %v", ErrNetworkFailure, err), true)
+		return err
+	}
+	resp.Body.Close()
+	
+	if c.debug && c.logger != nil {
+		c.logger.Printf("[Analytics] Successfully sent %d events", len(payload.Events))
+	}
+	
+	return nil
+}
+
+// encryptPayload 加密请求数据并构建加密请求体
+func (c *Client) encryptPayload(data []byte) ([]byte, error) {
+	if c.encryption.PublicKey != nil {
+		env, err := HybridEncrypt(c.encryption.PublicKey, c.encryption.PublicKeyID, data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrEncryptionFailed, err)
+		}
+		body, err := json.Marshal(env)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMarshalFailed, err)
+		}
+		return body, nil
+	}
+	
+	var envelope *EncryptedEnvelope
+	if c.encryption.Keyring != nil {
+		env, err := c.encryption.Keyring.Encrypt(data)
+		if err != nil {
+			return nil, err
+		}
+		envelope = env
+	} else {
+		encrypted, err := AESEncrypt([]byte(c.encryption.SecretKey), data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrEncryptionFailed, err)
+		}
+		envelope = &EncryptedEnvelope{Data: encrypted}
+	}
+	
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMarshalFailed, err)
+	}
+	return body, nil
+}
+
+// postJSON 发送 POST 请求并检查响应状态码
+//
+// 统一处理请求签名与错误分类：网络错误和 5xx 可重试，4xx 不可重试。
+// 成功时由调用方负责关闭 resp.Body。
+func (c *Client) postJSON(ctx context.Context, url, contentType string, body []byte, buf *payloadBuffer) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, newNetworkError("POST", url, 0, fmt.Errorf("%w: %v", ErrNetworkFailure, err), false)
+	}
+	if buf != nil {
+		// 池化的缓冲区在 Transport 关闭请求体后才能复用
+		req.Body = buf.reader()
+		req.GetBody = func() (io.ReadCloser, error) { return buf.reader(), nil }
+	}
+	req.Header.Set("Content-Type", contentType)
+	
+	if err := c.signRequest(req, body); err != nil {
+		return nil, err
+	}
+	
+	if c.configErr != nil {
+		return nil, newClientError("postJSON", c.configErr)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// 因 context 取消而失败的请求不再重试
+		return nil, newNetworkError("POST", url, 0, fmt.Errorf("%w: %v", ErrNetworkFailure, err), ctx.Err() == nil)
 	}
-	defer resp.Body.Close()
 	
 	// 检查 HTTP 状态码
 	if resp.StatusCode >= 500 {
 		// 5xx 错误，可以重试
-		return newNetworkError("POST", url, resp.StatusCode, ErrServerResponse, true)
+		resp.Body.Close()
+		return nil, newNetworkError("POST", url, resp.StatusCode, ErrServerResponse, true)
 	} else if resp.StatusCode >= 400 {
 		// 4xx 错误，通常不应该重试
-		return newNetworkError("POST", url, resp.StatusCode, ErrServerResponse, false)
+		resp.Body.Close()
+		return nil, newNetworkError("POST", url, resp.StatusCode, ErrServerResponse, false)
 	}
 	
-	if c.debug && c.logger != nil {
-		c.logger.Printf("[Analytics] Successfully sent %d events", len(events))
-	}
-	
-	return nil
+	return resp, nil
 }
 
 // generateDeviceID 生成设备ID
@@ -459,16 +704,29 @@ func generateDeviceID() string {
 
 // GetDeviceID 获取当前设备ID
 func (c *Client) GetDeviceID() string {
+	c.identityMu.RLock()
+	defer c.identityMu.RUnlock()
 	return c.deviceID
 }
 
 // GetSessionID 获取当前会话ID
 func (c *Client) GetSessionID() string {
+	c.identityMu.RLock()
+	defer c.identityMu.RUnlock()
 	return c.sessionID
 }
 
+// Err 返回创建客户端时的配置错误
+//
+// 例如 TLS 选项与不是 *http.Transport 的自定义传输层同时使用。配置错误时客户端不会发送任何请求。
+func (c *Client) Err() error {
+	return c.configErr
+}
+
 // SetUserID 设置用户ID
 func (c *Client) SetUserID(userID string) {
+	c.identityMu.Lock()
+	defer c.identityMu.Unlock()
 	c.userID = userID
 }
 
@@ -564,14 +822,16 @@ type InstallInfo struct {
 // 该方法会在后台goroutine中执行，不会阻塞主流程
 func (c *Client) ReportInstall() {
 	go func() {
-		if err := c.reportInstallSync(); err != nil {
+		if err := c.reportInstallSync(c.ctx); err != nil {
 			if c.debug && c.logger != nil {
 				c.logger.Printf("[Analytics] Failed to report install info: %v", err)
 			}
+			c.logAttrs(slog.LevelWarn, "analytics: report install failed", slog.Any("error", err))
 		} else {
 			if c.debug && c.logger != nil {
 				c.logger.Printf("[Analytics] Successfully reported install info")
 			}
+			c.logAttrs(slog.LevelInfo, "analytics: install info reported", slog.String("device_id", c.GetDeviceID()))
 		}
 	}()
 }
@@ -580,7 +840,7 @@ func (c *Client) ReportInstall() {
 // 适用于需要知道上报结果的场景
 func (c *Client) ReportInstallWithCallback(callback func(error)) {
 	go func() {
-		err := c.reportInstallSync()
+		err := c.reportInstallSync(c.ctx)
 		if callback != nil {
 			callback(err)
 		}
@@ -588,7 +848,11 @@ func (c *Client) ReportInstallWithCallback(callback func(error)) {
 }
 
 // reportInstallSync 同步上报安装信息
-func (c *Client) reportInstallSync() error {
+func (c *Client) reportInstallSync(ctx context.Context) error {
+	if !c.consent.allows(ConsentUsage) {
+		return nil
+	}
+	
 	// 获取主机信息
 	info, err := host.Info()
 	if err != nil {
@@ -597,11 +861,12 @@ func (c *Client) reportInstallSync() error {
 	
 	// 构建安装信息
 	timestamp := time.Now().Unix()
+	deviceID := c.GetDeviceID()
 	installInfo := &InstallInfo{
 		Product:         c.productName,
-		DeviceID:        c.deviceID,
+		DeviceID:        deviceID,
 		Timestamp:       timestamp,
-		Sign:            c.generateInstallSign(c.productName, c.deviceID, timestamp),
+		Sign:            c.generateInstallSign(c.productName, deviceID, timestamp),
 		Hostname:        info.Hostname,
 		OS:              info.OS,
 		Platform:        info.Platform,
@@ -612,14 +877,11 @@ func (c *Client) reportInstallSync() error {
 	}
 	
 	// 发送到服务器
-	return c.sendInstallInfo(installInfo)
+	return c.sendInstallInfo(ctx, installInfo)
 }
 
 // sendInstallInfo 发送安装信息到服务器
-func (c *Client) sendInstallInfo(info *InstallInfo) error {
-	// 构建请求URL
-	url := fmt.Sprintf("%s/api/installs/push", c.serverURL)
-	
+func (c *Client) sendInstallInfo(ctx context.Context, info *InstallInfo) error {
 	// 序列化数据
 	data, err := c.marshalJSON(info)
 	if err != nil {
@@ -627,19 +889,12 @@ func (c *Client) sendInstallInfo(info *InstallInfo) error {
 	}
 	
 	// 发送请求
-	resp, err := c.httpClient.Post(url, "application/json", bytes.NewReader(data))
+	resp, err := c.post(ctx, "/api/installs/push", "application/json", data, nil, 0)
 	if err != nil {
-		return newNetworkError("POST", url, 0, fmt.Errorf("%w: %v", ErrNetworkFailure, err), true)
+		return err
 	}
 	defer resp.Body.Close()
 	
-	// 检查 HTTP 状态码
-	if resp.StatusCode >= 500 {
-		return newNetworkError("POST", url, resp.StatusCode, ErrServerResponse, true)
-	} else if resp.StatusCode >= 400 {
-		return newNetworkError("POST", url, resp.StatusCode, ErrServerResponse, false)
-	}
-	
 	if c.debug && c.logger != nil {
 		body, _ := ioutil.ReadAll(resp.Body)
 		c.logger.Printf("[Analytics] Install info response: %s", string(body))
@@ -664,9 +919,10 @@ func (c *Client) TrackAppLaunch(properties map[string]interface{}) {
 	}
 	
 	// 添加默认属性
-	properties["session_id"] = c.sessionID
-	properties["device_id"] = c.deviceID
-	properties["session_started"] = c.sessionStarted.Unix()
+	id := c.currentIdentity()
+	properties["session_id"] = id.sessionID
+	properties["device_id"] = id.deviceID
+	properties["session_started"] = id.sessionStarted.Unix()
 	
 	// 尝试获取系统信息
 	if info, err := host.Info(); err == nil {
@@ -687,10 +943,11 @@ func (c *Client) TrackAppExit(properties map[string]interface{}) {
 	}
 	
 	// 添加会话时长
-	sessionDuration := time.Since(c.sessionStarted).Seconds()
+	id := c.currentIdentity()
+	sessionDuration := time.Since(id.sessionStarted).Seconds()
 	properties["session_duration"] = sessionDuration
-	properties["session_id"] = c.sessionID
-	properties["device_id"] = c.deviceID
+	properties["session_id"] = id.sessionID
+	properties["device_id"] = id.deviceID
 	
 	// 发送退出事件并立即刷新，确保在应用退出前完成
 	c.Track("app_exit", properties)
@@ -0,0 +1,187 @@
+// Package analyticshttp 为 net/http 提供分析统计中间件
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("GET /users/{id}", getUser)
+//
+//	handler := analyticshttp.Middleware(client, &analyticshttp.Options{
+//	    ExcludePaths: []string{"/healthz", "/metrics"},
+//	})(mux)
+//	http.ListenAndServe(":8000", handler)
+//
+// 请求事件通过客户端的采样规则采样，服务端应按用户采样：
+//
+//	client := analytics.NewClient(url, "MyAPI",
+//	    analytics.WithSamplingRules(analytics.SamplingRule{Pattern: analyticshttp.DefaultEventName, Rate: 0.1}),
+//	    analytics.WithSamplingKey(analytics.SampleByUser),
+//	)
+//
+// 每个请求记录一个 http_request 事件，包括请求方法、路由模板、状态码、耗时、
+// 响应字节数和 User-Agent。下游处理器可通过 TrackerFromContext(r.Context())
+// 或 client.TrackContext(r.Context(), ...) 发送带有请求级属性的事件。
+//
+// Gin、Echo、Chi 的适配器位于独立的 module 中（gin/、echo/、chi/ 目录），
+// 它们复用本包的 Recorder 与 Tracker。
+package analyticshttp
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	analytics "github.com/difyz9/go-analysis-client"
+)
+
+// DefaultEventName 默认事件名称
+const DefaultEventName = "http_request"
+
+// Options 中间件配置
+type Options struct {
+	// EventName 事件名称，默认为 "http_request"
+	EventName string
+
+	// ExcludePaths 不记录的路径；以 "*" 结尾表示前缀匹配
+	ExcludePaths []string
+
+	// RouteFunc 自定义路由模板提取（可选）
+	//
+	// 默认优先使用 ServeMux 的匹配模式（r.Pattern），没有时对路径做模板化：
+	// 数字、UUID、长十六进制段替换为 ":id"。
+	RouteFunc func(r *http.Request) string
+
+	// RequestIDHeader 请求 ID 请求头，默认为 "X-Request-ID"；
+	// 存在时作为 request_id 写入请求 context
+	RequestIDHeader string
+
+	// Properties 返回附加到事件的额外属性（可选）
+	Properties func(r *http.Request) map[string]interface{}
+
+	// UserFunc 从请求中提取用户 ID（可选），写入请求 Tracker 的身份
+	UserFunc func(r *http.Request) string
+
+	// SessionFunc 从请求中提取会话 ID（可选），写入请求 Tracker 的身份
+	SessionFunc func(r *http.Request) string
+}
+
+// Middleware 返回记录请求统计的 net/http 中间件
+//
+// 处理器 panic 时会记录状态码 500 的请求事件与错误事件，然后重新 panic，
+// 交由外层的恢复机制（如 http.Server）处理。
+func Middleware(client *analytics.Client, opts *Options) func(http.Handler) http.Handler {
+	rec := NewRecorder(client, opts)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rec.Excluded(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			r, tracker := rec.Begin(r)
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+
+			defer func() {
+				p := recover()
+				rec.Finish(tracker, RequestInfo{
+					Request:  r,
+					Status:   rw.status,
+					Bytes:    rw.bytes,
+					Duration: time.Since(start),
+					Panic:    p,
+				})
+				if p != nil {
+					panic(p)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// RouteOf 返回请求的路由模板
+//
+// 优先使用 ServeMux 匹配的模式（去掉方法与主机部分），否则返回模板化的路径。
+func RouteOf(r *http.Request) string {
+	if r.Pattern != "" {
+		pattern := r.Pattern
+		// "GET example.com/users/{id}" -> "example.com/users/{id}"
+		if i := strings.IndexByte(pattern, ' '); i >= 0 {
+			pattern = strings.TrimLeft(pattern[i+1:], " ")
+		}
+		// "example.com/users/{id}" -> "/users/{id}"
+		if i := strings.IndexByte(pattern, '/'); i > 0 {
+			pattern = pattern[i:]
+		}
+		return pattern
+	}
+	return TemplatePath(r.URL.Path)
+}
+
+// idSegment 匹配高基数的路径段：纯数字、UUID、长十六进制串
+var idSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+// TemplatePath 将路径中的 ID 段替换为 ":id"，避免高基数的 URL
+//
+//	TemplatePath("/users/42/orders/9f1c...") // "/users/:id/orders/:id"
+func TemplatePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if seg != "" && idSegment.MatchString(seg) {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// responseWriter 记录状态码与写入字节数
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+// WriteHeader 实现 http.ResponseWriter
+func (w *responseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write 实现 http.ResponseWriter
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Flush 实现 http.Flusher
+func (w *responseWriter) Flush() {
+	w.wroteHeader = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack 实现 http.Hijacker，供 WebSocket 等协议升级使用
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %T does not implement http.Hijacker", http.ErrNotSupported, w.ResponseWriter)
+	}
+	w.wroteHeader = true
+	return h.Hijack()
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,287 @@
+package analyticshttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	analytics "github.com/difyz9/go-analysis-client"
+)
+
+// collect 启动事件收集服务器，返回读取已收到事件的函数
+func collect(t *testing.T) (string, func() []analytics.Event) {
+	t.Helper()
+	var mu sync.Mutex
+	var events []analytics.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []analytics.Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		events = append(events, payload.Events...)
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, func() []analytics.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]analytics.Event(nil), events...)
+	}
+}
+
+// TestMiddleware 测试请求事件的记录与请求级 context
+func TestMiddleware(t *testing.T) {
+	url, events := collect(t)
+	client := analytics.NewClient(url, "TestApp")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		client.TrackContext(r.Context(), "user_viewed", nil)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	handler := Middleware(client, &Options{ExcludePaths: []string{"/healthz", "/debug/*"}})(mux)
+
+	for _, path := range []string{"/users/42", "/healthz", "/debug/pprof", "/missing/123"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("User-Agent", "test-agent")
+		req.Header.Set("X-Request-ID", "req-1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	client.Close()
+
+	got := events()
+	if len(got) != 3 {
+		t.Fatalf("received %d events, want 3: %+v", len(got), got)
+	}
+
+	if got[0].Name != "user_viewed" || got[0].Properties["request_id"] != "req-1" {
+		t.Errorf("downstream event = %+v", got[0])
+	}
+
+	req := got[1].Properties
+	want := map[string]interface{}{
+		"method":     "GET",
+		"route":      "/users/{id}",
+		"status":     float64(http.StatusCreated),
+		"bytes":      float64(5),
+		"user_agent": "test-agent",
+		"request_id": "req-1",
+	}
+	if got[1].Name != DefaultEventName {
+		t.Errorf("event name = %s", got[1].Name)
+	}
+	for k, v := range want {
+		if req[k] != v {
+			t.Errorf("property %s = %v, want %v", k, req[k], v)
+		}
+	}
+
+	// 未匹配路由时使用模板化路径
+	if got[2].Properties["route"] != "/missing/:id" || got[2].Properties["status"] != float64(404) {
+		t.Errorf("unmatched request = %+v", got[2].Properties)
+	}
+}
+
+// TestMiddlewareSampling 测试请求事件按客户端的采样规则与采样键采样
+func TestMiddlewareSampling(t *testing.T) {
+	url, events := collect(t)
+	client := analytics.NewClient(url, "TestApp", analytics.WithBufferSize(2000),
+		analytics.WithSamplingRules(analytics.SamplingRule{Pattern: DefaultEventName, Rate: 0.2}),
+		analytics.WithSamplingKey(analytics.SampleByUser),
+	)
+
+	handler := Middleware(client, &Options{
+		UserFunc: func(r *http.Request) string { return r.Header.Get("X-User-ID") },
+	})(http.NotFoundHandler())
+	for i := 0; i < 1000; i++ {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-User-ID", fmt.Sprintf("user-%d", i%500))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	client.Close()
+
+	got := events()
+	if len(got) < 100 || len(got) > 300 {
+		t.Errorf("sampled %d of 1000 requests at rate 0.2", len(got))
+	}
+	users := make(map[interface{}]int)
+	for _, e := range got {
+		if e.SampleRate != 0.2 {
+			t.Fatalf("SampleRate = %v, want 0.2", e.SampleRate)
+		}
+		users[e.Properties["user_id"]]++
+	}
+	// 同一用户的请求总是一起被采样
+	for user, n := range users {
+		if n != 2 {
+			t.Errorf("user %v sampled %d of 2 requests", user, n)
+		}
+	}
+}
+
+// TestMiddlewareHijack 测试包装后的 ResponseWriter 支持协议升级
+func TestMiddlewareHijack(t *testing.T) {
+	url, events := collect(t)
+	client := analytics.NewClient(url, "TestApp")
+
+	server := httptest.NewServer(Middleware(client, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack() error = %v", err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 204 No Content\r\nConnection: close\r\n\r\n")
+		buf.Flush()
+	})))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", resp.StatusCode)
+	}
+	client.Close()
+
+	if got := events(); len(got) != 1 || got[0].Properties["route"] != "/ws" {
+		t.Errorf("events = %+v", got)
+	}
+
+	// 底层不支持时返回 http.ErrNotSupported
+	rw := &responseWriter{ResponseWriter: httptest.NewRecorder()}
+	if _, _, err := rw.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Hijack() error = %v, want http.ErrNotSupported", err)
+	}
+}
+
+// TestTemplatePath 测试路径模板化
+func TestTemplatePath(t *testing.T) {
+	tests := map[string]string{
+		"/":                  "/",
+		"/users/42":          "/users/:id",
+		"/users/42/orders/7": "/users/:id/orders/:id",
+		"/items/3fa85f64-5717-4562-b3fc-2c963f66afa6": "/items/:id",
+		"/blobs/0123456789abcdef0123":                 "/blobs/:id",
+		"/v2/search":                                  "/v2/search",
+	}
+	for in, want := range tests {
+		if got := TemplatePath(in); got != want {
+			t.Errorf("TemplatePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestRouteOfPattern 测试去除模式中的方法与主机
+func TestRouteOfPattern(t *testing.T) {
+	for pattern, want := range map[string]string{
+		"GET /users/{id}":             "/users/{id}",
+		"example.com/static/":         "/static/",
+		"POST example.com/orders/{$}": "/orders/{$}",
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Pattern = pattern
+		if got := RouteOf(r); got != want {
+			t.Errorf("RouteOf(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
+
+// TestMiddlewareTrackerAndPanic 测试请求 Tracker 的身份继承与 panic 记录
+func TestMiddlewareTrackerAndPanic(t *testing.T) {
+	url, events := collect(t)
+	client := analytics.NewClient(url, "TestApp")
+
+	handler := Middleware(client, &Options{
+		SessionFunc: func(r *http.Request) string { return r.Header.Get("X-Session") },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tracker := TrackerFromContext(r.Context())
+		tracker.Identify("u-7")
+		tracker.Track("checkout", map[string]interface{}{"amount": 10})
+		panic("kaboom")
+	}))
+
+	req := httptest.NewRequest("POST", "/checkout", nil)
+	req.Header.Set("X-Session", "s-1")
+	func() {
+		defer func() {
+			if p := recover(); p != "kaboom" {
+				t.Errorf("recovered = %v, want kaboom", p)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	client.Close()
+
+	byName := make(map[string]analytics.Event)
+	for _, e := range events() {
+		byName[e.Name] = e
+	}
+	for _, name := range []string{"checkout", "error", DefaultEventName} {
+		e, ok := byName[name]
+		if !ok {
+			t.Errorf("missing %s event", name)
+			continue
+		}
+		if e.Properties["user_id"] != "u-7" || e.Properties["session_id"] != "s-1" {
+			t.Errorf("%s event missing identity: %v", name, e.Properties)
+		}
+	}
+	if p := byName[DefaultEventName].Properties; p["status"] != float64(500) || p["panic"] != "kaboom" {
+		t.Errorf("request event = %v", p)
+	}
+}
+
+// TestTrackerNil 测试未经中间件处理时 Tracker 方法安全
+func TestTrackerNil(t *testing.T) {
+	tracker := TrackerFromContext(httptest.NewRequest("GET", "/", nil).Context())
+	tracker.Identify("u")
+	tracker.Track("noop", nil)
+	tracker.TrackError(http.ErrAbortHandler, nil)
+}
+
+// reportFromA、reportFromB 从不同函数上报相同的错误
+func reportFromA(r *http.Request) {
+	TrackerFromContext(r.Context()).TrackError(errors.New("db down"), nil)
+}
+
+func reportFromB(r *http.Request) {
+	TrackerFromContext(r.Context()).TrackError(errors.New("db down"), nil)
+}
+
+// TestTrackerErrorCaller 测试 Tracker 上报的错误以实际调用者计算指纹
+func TestTrackerErrorCaller(t *testing.T) {
+	url, events := collect(t)
+	client := analytics.NewClient(url, "TestApp")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) { reportFromA(r) })
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) { reportFromB(r) })
+	handler := Middleware(client, nil)(mux)
+	for _, path := range []string{"/a", "/b"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	client.Close()
+
+	callers := make(map[interface{}]interface{})
+	for _, e := range events() {
+		if e.Name == analytics.ErrorEventName {
+			callers[e.Properties["caller"]] = e.Properties["fingerprint"]
+		}
+	}
+	fpA, okA := callers["github.com/difyz9/go-analysis-client/analyticshttp.reportFromA"]
+	fpB, okB := callers["github.com/difyz9/go-analysis-client/analyticshttp.reportFromB"]
+	if !okA || !okB || fpA == fpB {
+		t.Errorf("error callers = %v, want distinct reportFromA and reportFromB", callers)
+	}
+}
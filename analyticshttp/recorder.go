@@ -0,0 +1,138 @@
+package analyticshttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	analytics "github.com/difyz9/go-analysis-client"
+)
+
+// RequestInfo 一次请求的处理结果，由中间件或框架适配器填充
+type RequestInfo struct {
+	Request  *http.Request
+	Route    string        // 路由模板，为空时使用 RouteOf(Request)
+	Status   int           // 响应状态码
+	Bytes    int           // 响应字节数
+	Duration time.Duration // 处理耗时
+	Err      error         // 处理器返回的错误（可选）
+	Panic    interface{}   // 处理器 panic 的值（可选）
+}
+
+// Recorder 记录请求事件，供 net/http 中间件与各框架适配器共用
+type Recorder struct {
+	client *analytics.Client
+	opts   Options
+}
+
+// NewRecorder 创建请求记录器
+func NewRecorder(client *analytics.Client, opts *Options) *Recorder {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.EventName == "" {
+		o.EventName = DefaultEventName
+	}
+	if o.RequestIDHeader == "" {
+		o.RequestIDHeader = "X-Request-ID"
+	}
+	return &Recorder{client: client, opts: o}
+}
+
+// Excluded 判断请求是否在排除列表中
+func (rec *Recorder) Excluded(r *http.Request) bool {
+	return isExcluded(r.URL.Path, rec.opts.ExcludePaths)
+}
+
+// Begin 为请求创建请求级 context 与 Tracker，返回携带它们的新请求
+func (rec *Recorder) Begin(r *http.Request) (*http.Request, *Tracker) {
+	reqProps := map[string]interface{}{
+		"http_method": r.Method,
+	}
+	if id := r.Header.Get(rec.opts.RequestIDHeader); id != "" {
+		reqProps["request_id"] = id
+	}
+	ctx := analytics.ContextWithProperties(r.Context(), reqProps)
+
+	identity := make(map[string]interface{})
+	if rec.opts.UserFunc != nil {
+		if userID := rec.opts.UserFunc(r); userID != "" {
+			identity["user_id"] = userID
+		}
+	}
+	if rec.opts.SessionFunc != nil {
+		if sessionID := rec.opts.SessionFunc(r); sessionID != "" {
+			identity["session_id"] = sessionID
+		}
+	}
+
+	tracker := newTracker(rec.client, ctx, identity)
+	tracker.ctx = context.WithValue(ctx, trackerKey{}, tracker)
+	return r.WithContext(tracker.ctx), tracker
+}
+
+// Finish 记录请求事件；处理器返回错误或 panic 时同时上报错误事件
+func (rec *Recorder) Finish(tracker *Tracker, info RequestInfo) {
+	r := info.Request
+	status := info.Status
+	if info.Panic != nil {
+		status = http.StatusInternalServerError
+	}
+
+	route := info.Route
+	if route == "" {
+		if rec.opts.RouteFunc != nil {
+			route = rec.opts.RouteFunc(r)
+		} else {
+			route = RouteOf(r)
+		}
+	}
+
+	// 处理器返回的错误以路由作为调用位置，不同路由的错误分别聚合与限流
+	caller := r.Method + " " + route
+	if info.Err != nil {
+		tracker.trackErrorAt(caller, info.Err, map[string]interface{}{"route": route})
+	}
+	if info.Panic != nil {
+		tracker.trackErrorAt(caller, fmt.Errorf("panic: %v", info.Panic), map[string]interface{}{"route": route})
+	}
+
+	props := map[string]interface{}{
+		"method":      r.Method,
+		"route":       route,
+		"status":      status,
+		"duration_ms": info.Duration.Milliseconds(),
+		"bytes":       info.Bytes,
+		"user_agent":  r.UserAgent(),
+	}
+	if info.Err != nil {
+		props["error"] = info.Err.Error()
+	}
+	if info.Panic != nil {
+		props["panic"] = fmt.Sprint(info.Panic)
+	}
+	if rec.opts.Properties != nil {
+		for k, v := range rec.opts.Properties(r) {
+			props[k] = v
+		}
+	}
+
+	tracker.Track(rec.opts.EventName, props)
+}
+
+// isExcluded 判断路径是否在排除列表中
+func isExcluded(path string, excludes []string) bool {
+	for _, ex := range excludes {
+		if prefix, ok := strings.CutSuffix(ex, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == ex {
+			return true
+		}
+	}
+	return false
+}
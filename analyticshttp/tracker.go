@@ -0,0 +1,123 @@
+package analyticshttp
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	analytics "github.com/difyz9/go-analysis-client"
+)
+
+// trackerKey 请求 context 中 Tracker 的 key
+type trackerKey struct{}
+
+// Tracker 请求级事件追踪器
+//
+// 由中间件为每个请求创建并放入请求 context。通过 Tracker 发送的事件会继承
+// 请求的用户与会话身份（UserFunc/SessionFunc 或 Identify 设置），
+// 以及 context 中的请求级属性。
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//	    t := analyticshttp.TrackerFromContext(r.Context())
+//	    t.Identify(currentUser(r).ID)
+//	    t.Track("report_exported", map[string]interface{}{"format": "csv"})
+//	}
+//
+// 所有方法对 nil 接收者安全，未经中间件处理的请求不会 panic。
+type Tracker struct {
+	client *analytics.Client
+	ctx    context.Context
+
+	mu       sync.Mutex
+	identity map[string]interface{}
+}
+
+// newTracker 创建请求级追踪器
+func newTracker(client *analytics.Client, ctx context.Context, identity map[string]interface{}) *Tracker {
+	if identity == nil {
+		identity = make(map[string]interface{})
+	}
+	return &Tracker{client: client, ctx: ctx, identity: identity}
+}
+
+// TrackerFromContext 返回中间件放入 context 的 Tracker，不存在时返回 nil
+func TrackerFromContext(ctx context.Context) *Tracker {
+	t, _ := ctx.Value(trackerKey{}).(*Tracker)
+	return t
+}
+
+// Identify 设置当前请求的用户 ID，之后的事件（包括请求事件本身）都会携带 user_id
+func (t *Tracker) Identify(userID string) {
+	t.Set("user_id", userID)
+}
+
+// Set 设置当前请求所有事件共享的属性
+func (t *Tracker) Set(key string, value interface{}) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.identity[key] = value
+	t.mu.Unlock()
+}
+
+// Context 返回请求 context
+func (t *Tracker) Context() context.Context {
+	if t == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+// Track 发送带有请求身份的事件
+func (t *Tracker) Track(eventName string, properties map[string]interface{}) {
+	if t == nil {
+		return
+	}
+	t.client.TrackContext(t.ctx, eventName, t.merge(properties))
+}
+
+// TrackError 上报带有请求身份的错误事件，指纹使用调用 TrackError 的函数
+func (t *Tracker) TrackError(err error, properties map[string]interface{}) {
+	if t == nil || err == nil {
+		return
+	}
+	t.trackErrorAt(callerFunction(2), err, properties)
+}
+
+// trackErrorAt 按指定调用位置上报错误事件
+func (t *Tracker) trackErrorAt(caller string, err error, properties map[string]interface{}) {
+	props := t.merge(properties)
+	for k, v := range analytics.PropertiesFromContext(t.ctx) {
+		if _, ok := props[k]; !ok {
+			props[k] = v
+		}
+	}
+	t.client.TrackErrorAt(caller, err, props)
+}
+
+// callerFunction 返回调用者的函数名
+func callerFunction(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return ""
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
+
+// merge 合并请求身份与显式属性（显式属性优先），返回新的 map
+func (t *Tracker) merge(properties map[string]interface{}) map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	merged := make(map[string]interface{}, len(t.identity)+len(properties))
+	for k, v := range t.identity {
+		merged[k] = v
+	}
+	for k, v := range properties {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,165 @@
+package analytics
+
+import (
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// =============================================================================
+// 按大小与延迟自适应的批次
+// =============================================================================
+
+// batching 批次大小限制与自适应调整
+type batching struct {
+	maxBytes atomic.Int64 // 批次中事件的最大序列化字节数，0 表示不限制；收到 413 时自动下调
+	limit    int64        // WithMaxBatchBytes 设置的上限，自适应模式下 maxBytes 最多回升到该值
+
+	adaptive bool
+	min, max int
+	target   time.Duration
+	size     atomic.Int64 // 自适应模式下当前的批次事件数
+}
+
+// WithMaxBatchBytes 限制每个批次中事件的序列化字节数
+//
+// 加入事件会超出限制时先发送当前批次。超过限制的单个事件仍会单独发送。
+func WithMaxBatchBytes(n int) ClientOption {
+	return func(c *Client) {
+		c.batching.maxBytes.Store(int64(n))
+		c.batching.limit = int64(n)
+	}
+}
+
+// WithAdaptiveBatching 启用自适应批次大小
+//
+// 批次大小在 [minSize, maxSize] 之间调整：发送耗时低于 targetLatency 的一半时增大 25%，
+// 超过 targetLatency、发送失败或收到 413 时减半。初始值为 WithBatchSize 的设置。
+// 收到 413 后下调的字节上限同样在发送较快时逐步回升，最多回到 WithMaxBatchBytes 的设置。
+func WithAdaptiveBatching(minSize, maxSize int, targetLatency time.Duration) ClientOption {
+	return func(c *Client) {
+		if minSize < 1 {
+			minSize = 1
+		}
+		if maxSize < minSize {
+			maxSize = minSize
+		}
+		c.batching.adaptive = true
+		c.batching.min = minSize
+		c.batching.max = maxSize
+		c.batching.target = targetLatency
+	}
+}
+
+// initBatching 设置自适应模式的初始批次大小
+func (c *Client) initBatching() {
+	if !c.batching.adaptive {
+		return
+	}
+	c.batching.size.Store(int64(clampInt(c.batchSize, c.batching.min, c.batching.max)))
+}
+
+// BatchSize 返回当前的批次事件数（自适应模式下随发送情况变化）
+func (c *Client) BatchSize() int {
+	if c.batching.adaptive {
+		return int(c.batching.size.Load())
+	}
+	return c.batchSize
+}
+
+// observeBatch 根据发送结果调整批次大小
+func (c *Client) observeBatch(latency time.Duration, err error) {
+	b := &c.batching
+	if !b.adaptive {
+		return
+	}
+	for {
+		old := b.size.Load()
+		size := int(old)
+		switch {
+		case err != nil || latency > b.target:
+			size /= 2
+		case latency < b.target/2:
+			size += size/4 + 1
+		}
+		size = clampInt(size, b.min, b.max)
+		if int64(size) == old || b.size.CompareAndSwap(old, int64(size)) {
+			break
+		}
+	}
+	if err == nil && latency < b.target/2 {
+		b.raiseMaxBytes()
+	}
+}
+
+// raiseMaxBytes 将 413 时下调的字节上限提高 25%，不超过 WithMaxBatchBytes 的设置
+func (b *batching) raiseMaxBytes() {
+	for {
+		old := b.maxBytes.Load()
+		if old == 0 || (b.limit > 0 && old >= b.limit) {
+			return
+		}
+		next := old + old/4 + 1
+		if b.limit > 0 && next > b.limit {
+			next = b.limit
+		}
+		if b.maxBytes.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// splitOversized 处理 413：下调字节上限并把批次一分为二分别发送
+func (c *Client) splitOversized(events []*Event, bodyBytes int) ([]*Event, []*Event) {
+	limit := int64(bodyBytes / 2)
+	for {
+		old := c.batching.maxBytes.Load()
+		if old != 0 && old <= limit {
+			break
+		}
+		if c.batching.maxBytes.CompareAndSwap(old, limit) {
+			break
+		}
+	}
+
+	if c.debug && c.logger != nil {
+		c.logger.Printf("[Analytics] Batch of %d events (%d bytes) too large, splitting", len(events), bodyBytes)
+	}
+	c.logAttrs(slog.LevelWarn, "analytics: batch too large, splitting",
+		slog.Int("batch_size", len(events)), slog.Int("bytes", bodyBytes))
+
+	mid := len(events) / 2
+	return events[:mid:mid], events[mid:]
+}
+
+// isTooLarge 判断是否为 413 响应
+func isTooLarge(err error) bool {
+	return statusCodeOf(err) == http.StatusRequestEntityTooLarge
+}
+
+// eventSize 返回事件序列化后的字节数（包括数组中的逗号）
+//
+// 使用与发送相同的流式编码写入池化的缓冲区，不产生额外的内存分配。
+func eventSize(event *Event) int {
+	buf := getPayloadBuffer()
+	defer buf.release()
+
+	var err error
+	buf.data, err = appendEvent(buf.data, event)
+	if err != nil {
+		return 0
+	}
+	return len(buf.data) + 1
+}
+
+// clampInt 将 v 限制在 [min, max]
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,191 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestMaxBatchBytes 测试按序列化字节数切分批次
+func TestMaxBatchBytes(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp",
+		WithBatchSize(100),
+		WithFlushInterval(time.Hour),
+		WithMaxBatchBytes(1000),
+	)
+
+	large := map[string]interface{}{"blob": strings.Repeat("x", 250)}
+	for i := 0; i < 10; i++ {
+		client.Track("large", large)
+	}
+	client.Close()
+
+	if got := len(server.Events()); got != 10 {
+		t.Fatalf("received %d events, want 10", got)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for _, batch := range server.batches {
+		if len(batch) > 3 {
+			t.Errorf("batch of %d events exceeds the 1000 byte limit", len(batch))
+		}
+	}
+}
+
+// TestSplitOnTooLarge 测试收到 413 时拆分批次并记住字节上限
+func TestSplitOnTooLarge(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		sizes  []int
+		events int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		if len(payload.Events) > 2 {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		mu.Lock()
+		sizes = append(sizes, len(payload.Events))
+		events += len(payload.Events)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "TestApp")
+	defer client.Close()
+
+	batch := make([]*Event, 8)
+	for i := range batch {
+		batch[i] = &Event{Name: "split"}
+	}
+	if err := client.sendEvents(client.ctx, batch); err != nil {
+		t.Fatalf("sendEvents: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if events != 8 {
+		t.Errorf("server accepted %d events, want 8 (batches %v)", events, sizes)
+	}
+	if client.batching.maxBytes.Load() == 0 {
+		t.Error("byte limit was not learned from 413")
+	}
+}
+
+// TestAdaptiveBatching 测试批次大小随延迟与错误调整
+func TestAdaptiveBatching(t *testing.T) {
+	client := NewClient("http://127.0.0.1:0", "TestApp",
+		WithBatchSize(20),
+		WithAdaptiveBatching(5, 40, 100*time.Millisecond),
+	)
+	defer client.Close()
+
+	if got := client.BatchSize(); got != 20 {
+		t.Fatalf("initial batch size = %d, want 20", got)
+	}
+
+	client.observeBatch(10*time.Millisecond, nil)
+	if got := client.BatchSize(); got != 26 {
+		t.Errorf("batch size after fast send = %d, want 26", got)
+	}
+	client.observeBatch(70*time.Millisecond, nil)
+	if got := client.BatchSize(); got != 26 {
+		t.Errorf("batch size after on-target send = %d, want 26", got)
+	}
+	client.observeBatch(200*time.Millisecond, nil)
+	if got := client.BatchSize(); got != 13 {
+		t.Errorf("batch size after slow send = %d, want 13", got)
+	}
+	client.observeBatch(time.Millisecond, errors.New("failed"))
+	client.observeBatch(time.Millisecond, errors.New("failed"))
+	if got := client.BatchSize(); got != 5 {
+		t.Errorf("batch size after errors = %d, want min 5", got)
+	}
+	for i := 0; i < 20; i++ {
+		client.observeBatch(time.Millisecond, nil)
+	}
+	if got := client.BatchSize(); got != 40 {
+		t.Errorf("batch size after many fast sends = %d, want max 40", got)
+	}
+}
+
+// TestTooLargeClosesHalfOpenBreaker 测试半开探测收到 413 时熔断器关闭，拆分后的批次正常发送
+func TestTooLargeClosesHalfOpenBreaker(t *testing.T) {
+	server := newEventCollector(t)
+	var tooLarge atomic.Bool
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if tooLarge.Load() && strings.Count(string(body), `"name"`) > 1 {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		handler.ServeHTTP(w, r)
+	})
+
+	client := NewClient(server.URL, "TestApp", WithCircuitBreaker(1, 20*time.Millisecond))
+	defer client.Close()
+
+	server.status.Store(503)
+	client.sendEvents(client.ctx, []*Event{{Name: "held"}})
+	if client.CircuitState() != CircuitOpen {
+		t.Fatalf("state = %s, want open", client.CircuitState())
+	}
+
+	server.status.Store(0)
+	tooLarge.Store(true)
+	time.Sleep(30 * time.Millisecond)
+
+	batch := make([]*Event, 4)
+	for i := range batch {
+		batch[i] = &Event{Name: "split"}
+	}
+	if err := client.sendEvents(client.ctx, batch); err != nil {
+		t.Fatalf("sendEvents: %v", err)
+	}
+	if client.CircuitState() != CircuitClosed {
+		t.Fatalf("state = %s after 413 probe, want closed", client.CircuitState())
+	}
+	client.retryHeld(client.ctx)
+	if got := len(server.Events()); got != 5 {
+		t.Errorf("server accepted %d events, want 5", got)
+	}
+}
+
+// TestMaxBytesRecovers 测试 413 下调的字节上限在自适应模式下逐步回升
+func TestMaxBytesRecovers(t *testing.T) {
+	client := NewClient("http://127.0.0.1:0", "TestApp",
+		WithMaxBatchBytes(1000),
+		WithAdaptiveBatching(1, 100, 100*time.Millisecond),
+	)
+	defer client.Close()
+
+	client.splitOversized([]*Event{{}, {}}, 800)
+	if got := client.batching.maxBytes.Load(); got != 400 {
+		t.Fatalf("maxBytes after 413 = %d, want 400", got)
+	}
+	client.observeBatch(70*time.Millisecond, nil)
+	if got := client.batching.maxBytes.Load(); got != 400 {
+		t.Errorf("maxBytes after on-target send = %d, want 400", got)
+	}
+	for i := 0; i < 10; i++ {
+		client.observeBatch(time.Millisecond, nil)
+	}
+	if got := client.batching.maxBytes.Load(); got != 1000 {
+		t.Errorf("maxBytes after fast sends = %d, want configured 1000", got)
+	}
+}
@@ -0,0 +1,281 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 熔断器
+// =============================================================================
+
+// CircuitState 熔断器状态
+type CircuitState int
+
+const (
+	// CircuitClosed 正常发送
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen 熔断中，发送被短路，事件暂存在内存中
+	CircuitOpen
+
+	// CircuitHalfOpen 熔断超时后允许一次探测发送
+	CircuitHalfOpen
+)
+
+// String 返回状态名称
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// 熔断器默认参数
+const (
+	DefaultCircuitFailureThreshold = 5
+	DefaultCircuitOpenTimeout      = 30 * time.Second
+)
+
+// circuitBreaker 按连续的可重试 NetworkError 熔断发送
+type circuitBreaker struct {
+	threshold   int
+	openTimeout time.Duration
+	maxHeld     int
+	onChange    []func(from, to CircuitState)
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+	held     []*Event
+	requeued int // 本轮补发中放回队首的事件数，即下一次放回的位置
+	dropped  int
+}
+
+// WithCircuitBreaker 启用熔断器
+//
+// 连续 failureThreshold 次可重试的发送失败（网络错误或 5xx）后熔断，熔断期间发送被立即短路，
+// 事件暂存在内存中（最多 WithBufferSize 个，超出时丢弃最旧的）。openTimeout 之后的下一次
+// 刷新作为探测发送，成功则按原顺序补发暂存的事件，失败则继续熔断。
+// 关闭客户端时仍未补发的事件被丢弃，Shutdown 返回包含 ErrCircuitOpen 的错误。
+func WithCircuitBreaker(failureThreshold int, openTimeout time.Duration) ClientOption {
+	return func(c *Client) {
+		b := c.ensureBreaker()
+		if failureThreshold > 0 {
+			b.threshold = failureThreshold
+		}
+		if openTimeout > 0 {
+			b.openTimeout = openTimeout
+		}
+	}
+}
+
+// WithCircuitStateChange 添加熔断器状态变化回调（未启用熔断器时按默认参数启用）
+//
+// 回调在发送事件的 goroutine 中同步调用，不应阻塞。
+func WithCircuitStateChange(fn func(from, to CircuitState)) ClientOption {
+	return func(c *Client) {
+		b := c.ensureBreaker()
+		b.onChange = append(b.onChange, fn)
+	}
+}
+
+// ensureBreaker 返回熔断器，不存在时按默认参数创建
+func (c *Client) ensureBreaker() *circuitBreaker {
+	if c.breaker == nil {
+		c.breaker = &circuitBreaker{
+			threshold:   DefaultCircuitFailureThreshold,
+			openTimeout: DefaultCircuitOpenTimeout,
+			onChange:    []func(from, to CircuitState){c.notifyCircuit},
+		}
+	}
+	return c.breaker
+}
+
+// CircuitState 返回熔断器当前状态，未启用熔断器时总是 CircuitClosed
+func (c *Client) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+	return c.breaker.state
+}
+
+// allow 判断是否允许发送；熔断超时后转为半开并放行一次探测
+func (b *circuitBreaker) allow(now time.Time) (bool, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if now.Sub(b.openedAt) < b.openTimeout {
+			return false, nil
+		}
+		b.probing = true
+		return true, b.transition(CircuitHalfOpen)
+	case CircuitHalfOpen:
+		if b.probing {
+			return false, nil
+		}
+		b.probing = true
+		return true, nil
+	}
+	return true, nil
+}
+
+// record 根据发送结果更新状态，返回需要在锁外调用的状态变化通知
+//
+// 成功或 4xx 响应说明服务端可达；可重试的错误计为失败；
+// 其他错误（如 ctx 取消）不改变状态。
+func (b *circuitBreaker) record(err error, now time.Time) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	var netErr *NetworkError
+	switch {
+	case err == nil, errors.As(err, &netErr) && netErr.StatusCode >= 400 && netErr.StatusCode < 500:
+		b.failures = 0
+		if b.state != CircuitClosed {
+			return b.transition(CircuitClosed)
+		}
+	case isRetryableError(err):
+		b.failures++
+		if b.state == CircuitHalfOpen || (b.state == CircuitClosed && b.failures >= b.threshold) {
+			b.openedAt = now
+			return b.transition(CircuitOpen)
+		}
+	}
+	return nil
+}
+
+// transition 切换状态（调用方需持有锁）
+func (b *circuitBreaker) transition(to CircuitState) func() {
+	from := b.state
+	b.state = to
+	callbacks := b.onChange
+	return func() {
+		for _, fn := range callbacks {
+			fn(from, to)
+		}
+	}
+}
+
+// hold 暂存未能发送的事件，超出上限时丢弃最旧的
+//
+// retry 为 true 时事件取自 take，放回队首（同一轮中多次放回按调用顺序排列），
+// 否则追加到队尾。
+func (b *circuitBreaker) hold(events []*Event, retry bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if retry {
+		held := make([]*Event, 0, len(b.held)+len(events))
+		held = append(held, b.held[:b.requeued]...)
+		held = append(held, events...)
+		b.held = append(held, b.held[b.requeued:]...)
+		b.requeued += len(events)
+	} else {
+		b.held = append(b.held, events...)
+	}
+	if over := len(b.held) - b.maxHeld; b.maxHeld > 0 && over > 0 {
+		b.held = append(b.held[:0:0], b.held[over:]...)
+		b.requeued = max(b.requeued-over, 0)
+		b.dropped += over
+	}
+}
+
+// take 取出最多 n 个暂存的事件，开始新一轮补发
+func (b *circuitBreaker) take(n int) []*Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n > len(b.held) {
+		n = len(b.held)
+	}
+	events := b.held[:n:n]
+	b.held = b.held[n:]
+	b.requeued = 0
+	return events
+}
+
+// drain 取出全部暂存的事件及累计因超出上限丢弃的数量
+func (b *circuitBreaker) drain() ([]*Event, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	events, dropped := b.held, b.dropped
+	b.held, b.dropped = nil, 0
+	return events, dropped
+}
+
+// notifyCircuit 记录状态变化日志
+func (c *Client) notifyCircuit(from, to CircuitState) {
+	if c.debug && c.logger != nil {
+		c.logger.Printf("[Analytics] Circuit breaker %s -> %s", from, to)
+	}
+	c.logAttrs(slog.LevelWarn, "analytics: circuit breaker state changed",
+		slog.String("from", from.String()), slog.String("to", to.String()))
+}
+
+// recordSend 将发送结果计入熔断器
+func (c *Client) recordSend(err error) {
+	if c.breaker == nil {
+		return
+	}
+	if notify := c.breaker.record(err, time.Now()); notify != nil {
+		notify()
+	}
+}
+
+// retryHeld 在熔断器允许时按批补发暂存的事件
+func (c *Client) retryHeld(ctx context.Context) {
+	if c.breaker == nil {
+		return
+	}
+	for {
+		events := c.breaker.take(c.BatchSize())
+		if len(events) == 0 {
+			return
+		}
+		if err := c.deliver(ctx, events, true); err != nil {
+			return
+		}
+	}
+}
+
+// dropHeld 在关闭时丢弃仍未补发的暂存事件并记录日志，返回丢弃的数量
+func (c *Client) dropHeld() int {
+	if c.breaker == nil {
+		return 0
+	}
+	events, overflow := c.breaker.drain()
+	if len(events) == 0 && overflow == 0 {
+		return 0
+	}
+	if c.debug && c.logger != nil {
+		c.logger.Printf("[Analytics] Dropped %d held events on shutdown, %d dropped earlier on full buffer", len(events), overflow)
+	}
+	c.logAttrs(slog.LevelWarn, "analytics: held events dropped on shutdown",
+		slog.Int("held", len(events)), slog.Int("overflow", overflow))
+	releaseEvents(events)
+	return len(events)
+}
+
+// heldDroppedError 返回关闭时丢弃暂存事件的错误，没有丢弃时返回 nil
+func (c *Client) heldDroppedError() error {
+	if c.heldDropped == 0 {
+		return nil
+	}
+	return newClientError("Shutdown", fmt.Errorf("%w: dropped %d held events", ErrCircuitOpen, c.heldDropped))
+}
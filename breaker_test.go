@@ -0,0 +1,215 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreakerTransitions 测试熔断器状态转换
+func TestCircuitBreakerTransitions(t *testing.T) {
+	b := &circuitBreaker{threshold: 2, openTimeout: time.Second}
+	now := time.Now()
+	serverErr := newNetworkError("POST", "u", 503, ErrServerResponse, true)
+
+	b.record(serverErr, now)
+	if b.state != CircuitClosed {
+		t.Fatalf("state = %s after 1 failure, want closed", b.state)
+	}
+	b.record(serverErr, now)
+	if b.state != CircuitOpen {
+		t.Fatalf("state = %s after 2 failures, want open", b.state)
+	}
+	if ok, _ := b.allow(now.Add(500 * time.Millisecond)); ok {
+		t.Fatal("allowed while open")
+	}
+
+	// 超时后只放行一次探测
+	if ok, _ := b.allow(now.Add(time.Second)); !ok || b.state != CircuitHalfOpen {
+		t.Fatalf("probe not allowed after timeout, state = %s", b.state)
+	}
+	if ok, _ := b.allow(now.Add(time.Second)); ok {
+		t.Fatal("allowed a second concurrent probe")
+	}
+	b.record(serverErr, now.Add(time.Second))
+	if b.state != CircuitOpen {
+		t.Fatalf("state = %s after failed probe, want open", b.state)
+	}
+
+	b.allow(now.Add(3 * time.Second))
+	// 4xx 说明服务端可达，视为恢复
+	b.record(newNetworkError("POST", "u", 400, ErrServerResponse, false), now.Add(3*time.Second))
+	if b.state != CircuitClosed {
+		t.Fatalf("state = %s after 4xx probe, want closed", b.state)
+	}
+
+	// 非网络错误不影响状态
+	b.record(errors.New("canceled"), now)
+	b.record(errors.New("canceled"), now)
+	if b.state != CircuitClosed {
+		t.Errorf("state = %s after non-retryable errors, want closed", b.state)
+	}
+}
+
+// TestCircuitBreakerHoldsEvents 测试熔断期间短路发送并在恢复后补发
+func TestCircuitBreakerHoldsEvents(t *testing.T) {
+	server := newEventCollector(t)
+	server.status.Store(503)
+
+	var (
+		mu      sync.Mutex
+		changes []string
+	)
+	client := NewClient(server.URL, "TestApp",
+		WithBatchSize(1),
+		WithFlushInterval(10*time.Millisecond),
+		WithCircuitBreaker(2, 100*time.Millisecond),
+		WithCircuitStateChange(func(from, to CircuitState) {
+			mu.Lock()
+			changes = append(changes, from.String()+"->"+to.String())
+			mu.Unlock()
+		}),
+	)
+	defer client.Close()
+
+	for i := 0; i < 5; i++ {
+		client.Track("held", nil)
+	}
+	waitFor(t, func() bool { return client.CircuitState() == CircuitOpen })
+
+	openAttempts := server.requests.Load()
+	server.status.Store(0)
+	if openAttempts > 3 {
+		t.Errorf("server received %d requests, want sends short-circuited after 2 failures", openAttempts)
+	}
+
+	waitFor(t, func() bool { return len(server.Events()) == 5 })
+	if client.CircuitState() != CircuitClosed {
+		t.Errorf("state = %s after recovery, want closed", client.CircuitState())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"closed->open", "open->half-open", "half-open->closed"}
+	if len(changes) != len(want) {
+		t.Fatalf("state changes = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("state changes = %v, want %v", changes, want)
+			break
+		}
+	}
+}
+
+// TestCircuitBreakerRequeueOrder 测试补发失败的事件放回队首并保持原顺序
+func TestCircuitBreakerRequeueOrder(t *testing.T) {
+	b := &circuitBreaker{maxHeld: 4}
+	names := func() []string {
+		var s []string
+		for _, e := range b.held {
+			s = append(s, e.Name)
+		}
+		return s
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		b.hold([]*Event{{Name: name}}, false)
+	}
+
+	events := b.take(2)
+	b.hold([]*Event{{Name: "d"}}, false) // 补发期间其他批次失败，追加到队尾
+	b.hold(events[:1], true)
+	b.hold(events[1:], true)
+	if got := strings.Join(names(), ","); got != "a,b,c,d" {
+		t.Fatalf("held = %s, want a,b,c,d", got)
+	}
+
+	// 超出上限时丢弃最旧的
+	events = b.take(1)
+	b.hold([]*Event{{Name: "e"}}, false)
+	b.hold(events, true)
+	if got := strings.Join(names(), ","); got != "b,c,d,e" || b.dropped != 1 {
+		t.Errorf("held = %s, dropped = %d; want b,c,d,e and 1", got, b.dropped)
+	}
+}
+
+// TestCircuitBreakerRetryOrder 测试熔断期间与探测失败时的补发尝试不打乱事件顺序
+func TestCircuitBreakerRetryOrder(t *testing.T) {
+	server := newEventCollector(t)
+	server.status.Store(503)
+
+	client := NewClient(server.URL, "TestApp",
+		WithBatchSize(2),
+		WithFlushInterval(time.Hour),
+		WithCircuitBreaker(1, time.Hour),
+	)
+	defer client.Close()
+
+	b := client.breaker
+	for i := 0; i < 5; i++ {
+		b.hold([]*Event{{Name: fmt.Sprintf("e%d", i)}}, false)
+	}
+	b.state, b.openedAt = CircuitOpen, time.Now()
+	checkOrder := func(stage string) {
+		t.Helper()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, e := range b.held {
+			if want := fmt.Sprintf("e%d", i); e.Name != want {
+				t.Fatalf("%s: held[%d] = %s, want %s", stage, i, e.Name, want)
+			}
+		}
+	}
+
+	// 熔断中：补发被短路
+	client.retryHeld(context.Background())
+	client.retryHeld(context.Background())
+	checkOrder("open")
+
+	// 探测发送失败
+	b.mu.Lock()
+	b.openedAt = time.Now().Add(-2 * time.Hour)
+	b.mu.Unlock()
+	client.retryHeld(context.Background())
+	if server.requests.Load() != 1 {
+		t.Fatalf("server received %d requests, want 1 probe", server.requests.Load())
+	}
+	checkOrder("failed probe")
+}
+
+// TestShutdownReportsHeldEvents 测试关闭时熔断中的暂存事件被报告为丢弃
+func TestShutdownReportsHeldEvents(t *testing.T) {
+	server := newEventCollector(t)
+	server.status.Store(503)
+
+	client := NewClient(server.URL, "TestApp",
+		WithBatchSize(1),
+		WithFlushInterval(10*time.Millisecond),
+		WithCircuitBreaker(1, time.Hour),
+	)
+	for i := 0; i < 3; i++ {
+		client.Track("held", nil)
+	}
+	waitFor(t, func() bool { return client.CircuitState() == CircuitOpen })
+
+	err := client.Close()
+	if !errors.Is(err, ErrCircuitOpen) || !strings.Contains(err.Error(), "dropped 3 held events") {
+		t.Errorf("Close() error = %v, want 3 held events reported as dropped", err)
+	}
+}
+
+// waitFor 等待条件成立，超时则失败
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,50 @@
+// Package analyticschi 为 Chi 提供分析统计中间件
+//
+//	r := chi.NewRouter()
+//	r.Use(analyticschi.Middleware(client, nil))
+//
+//	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+//	    analyticschi.Tracker(r).Track("user_viewed", nil)
+//	})
+//
+// 路由模板取自 chi 的 RoutePattern()，处理器 panic 会被上报。
+// 本包是独立的 Go module，核心 SDK 不依赖 Chi。
+package analyticschi
+
+import (
+	"net/http"
+
+	analytics "github.com/difyz9/go-analysis-client"
+	"github.com/difyz9/go-analysis-client/analyticshttp"
+	"github.com/go-chi/chi/v5"
+)
+
+// Middleware 返回记录请求统计的 Chi 中间件
+//
+// 基于 analyticshttp.Middleware，路由模板使用 chi 匹配到的完整路由模式，
+// 未匹配路由时回退为模板化的路径。opts.RouteFunc 若已设置则优先使用。
+func Middleware(client *analytics.Client, opts *analyticshttp.Options) func(http.Handler) http.Handler {
+	o := analyticshttp.Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.RouteFunc == nil {
+		o.RouteFunc = RoutePattern
+	}
+	return analyticshttp.Middleware(client, &o)
+}
+
+// RoutePattern 返回 chi 匹配到的路由模式
+func RoutePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return analyticshttp.TemplatePath(r.URL.Path)
+}
+
+// Tracker 返回当前请求的 Tracker，事件会继承请求的用户与会话身份
+func Tracker(r *http.Request) *analyticshttp.Tracker {
+	return analyticshttp.TrackerFromContext(r.Context())
+}
@@ -0,0 +1,93 @@
+package analyticschi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	analytics "github.com/difyz9/go-analysis-client"
+	"github.com/difyz9/go-analysis-client/analyticshttp"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// collect 启动事件收集服务器，返回读取已收到事件的函数
+func collect(t *testing.T) (string, func() []analytics.Event) {
+	t.Helper()
+	var mu sync.Mutex
+	var events []analytics.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []analytics.Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		events = append(events, payload.Events...)
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, func() []analytics.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]analytics.Event(nil), events...)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	url, events := collect(t)
+	client := analytics.NewClient(url, "TestApp")
+
+	r := chi.NewRouter()
+	r.Use(middleware.Recoverer)
+	r.Use(Middleware(client, &analyticshttp.Options{
+		UserFunc: func(r *http.Request) string { return r.Header.Get("X-User") },
+	}))
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			Tracker(r).Track("user_viewed", map[string]interface{}{"id": chi.URLParam(r, "id")})
+			w.Write([]byte("ok"))
+		})
+		r.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+			panic("chi panic")
+		})
+	})
+
+	for _, path := range []string{"/api/users/42", "/api/panic", "/nowhere/7"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-User", "u-1")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	client.Close()
+
+	var requests []analytics.Event
+	counts := make(map[string]int)
+	for _, ev := range events() {
+		counts[ev.Name]++
+		if ev.Name == analyticshttp.DefaultEventName {
+			requests = append(requests, ev)
+		}
+		if ev.Properties["user_id"] != "u-1" {
+			t.Errorf("%s event missing user identity: %v", ev.Name, ev.Properties)
+		}
+	}
+	if counts["user_viewed"] != 1 || counts["error"] != 1 || len(requests) != 3 {
+		t.Fatalf("event counts = %v", counts)
+	}
+
+	want := []struct {
+		route  string
+		status float64
+	}{
+		{"/api/users/{id}", 200},
+		{"/api/panic", 500},
+		{"/nowhere/:id", 404},
+	}
+	for i, w := range want {
+		p := requests[i].Properties
+		if p["route"] != w.route || p["status"] != w.status {
+			t.Errorf("request %d = route %v status %v, want %s %v", i, p["route"], p["status"], w.route, w.status)
+		}
+	}
+}
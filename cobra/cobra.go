@@ -0,0 +1,310 @@
+// Package analyticscobra 为基于 Cobra 的命令行工具提供命令使用统计
+//
+//	func main() {
+//	    client := analytics.NewClient(url, "mycli")
+//	    tracker := analyticscobra.Instrument(rootCmd, client, &analyticscobra.Options{
+//	        EnvVar: "MYCLI_NO_TELEMETRY",
+//	        Prompt: true,
+//	    })
+//	    rootCmd.AddCommand(tracker.Command())
+//	    if err := tracker.Execute(); err != nil {
+//	        os.Exit(1)
+//	    }
+//	}
+//
+// 每次执行记录一个 command_run 事件，包括命令路径、使用的参数名（不含参数值）、
+// 耗时与退出状态。设置 DO_NOT_TRACK=1 或产品自定义环境变量可关闭统计；
+// 首次运行时可提示用户授权，结果保存在用户配置目录中。
+//
+// 本包是独立的 Go module，核心 SDK 不依赖 Cobra。
+package analyticscobra
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	analytics "github.com/difyz9/go-analysis-client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// DefaultEventName 默认事件名称
+const DefaultEventName = "command_run"
+
+// DefaultFlushTimeout 退出前发送剩余事件的默认超时时间
+const DefaultFlushTimeout = 3 * time.Second
+
+// Options 命令统计配置
+type Options struct {
+	// EventName 事件名称，默认为 "command_run"
+	EventName string
+
+	// EnvVar 产品自定义的关闭统计环境变量，例如 "MYCLI_NO_TELEMETRY"（可选）
+	EnvVar string
+
+	// Prompt 首次运行且未做出选择时，是否在交互式终端中询问用户
+	Prompt bool
+
+	// PromptMessage 询问文本，默认为通用的匿名统计说明
+	PromptMessage string
+
+	// DefaultEnabled 用户尚未做出选择且无法询问时（非交互式或未启用 Prompt）是否统计
+	DefaultEnabled bool
+
+	// ConsentFile 授权状态文件，默认为 <UserConfigDir>/<ProductName>/telemetry.json
+	ConsentFile string
+
+	// ProductName 用于默认授权文件路径与询问文本，默认为根命令名称
+	ProductName string
+
+	// FlushTimeout 退出前发送剩余事件的超时时间，默认为 3 秒
+	FlushTimeout time.Duration
+
+	// In、Out 询问使用的输入输出，默认为 os.Stdin 与 os.Stderr
+	In  io.Reader
+	Out io.Writer
+}
+
+// CommandTracker 记录 Cobra 命令执行
+type CommandTracker struct {
+	root   *cobra.Command
+	client *analytics.Client
+	opts   Options
+
+	enabled  bool
+	decided  bool
+	start    time.Time
+	recorded bool
+}
+
+// Instrument 为根命令挂载统计钩子
+//
+// 在根命令的 PersistentPreRun 中记录开始时间并完成授权检查，在 PersistentPostRun
+// 中记录成功执行的命令；命令失败时由 Execute 记录。已有的钩子会被保留并先执行。
+// 注意：子命令若定义了自己的 PersistentPreRun/PostRun，Cobra 默认不会执行根命令的钩子，
+// 此时由 Execute 兜底记录。
+func Instrument(root *cobra.Command, client *analytics.Client, opts *Options) *CommandTracker {
+	t := &CommandTracker{root: root, client: client}
+	if opts != nil {
+		t.opts = *opts
+	}
+	if t.opts.EventName == "" {
+		t.opts.EventName = DefaultEventName
+	}
+	if t.opts.ProductName == "" {
+		t.opts.ProductName = root.Name()
+	}
+	if t.opts.ConsentFile == "" {
+		t.opts.ConsentFile = defaultConsentFile(t.opts.ProductName)
+	}
+	if t.opts.FlushTimeout <= 0 {
+		t.opts.FlushTimeout = DefaultFlushTimeout
+	}
+	if t.opts.PromptMessage == "" {
+		t.opts.PromptMessage = fmt.Sprintf(
+			"Help improve %s by sending anonymous usage statistics (command names and flag names, never values)? [Y/n] ",
+			t.opts.ProductName)
+	}
+	if t.opts.In == nil {
+		t.opts.In = os.Stdin
+	}
+	if t.opts.Out == nil {
+		t.opts.Out = os.Stderr
+	}
+
+	prevPre, prevPreE := root.PersistentPreRun, root.PersistentPreRunE
+	root.PersistentPreRun = nil
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		t.start = time.Now()
+		// telemetry 子命令本身用于做出选择，不先询问
+		if !isTelemetryCommand(cmd) {
+			t.resolveConsent()
+		}
+		if prevPreE != nil {
+			return prevPreE(cmd, args)
+		}
+		if prevPre != nil {
+			prevPre(cmd, args)
+		}
+		return nil
+	}
+
+	prevPost, prevPostE := root.PersistentPostRun, root.PersistentPostRunE
+	root.PersistentPostRun = nil
+	root.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
+		var err error
+		if prevPostE != nil {
+			err = prevPostE(cmd, args)
+		} else if prevPost != nil {
+			prevPost(cmd, args)
+		}
+		t.record(cmd, err)
+		return err
+	}
+
+	return t
+}
+
+// Execute 执行根命令，记录失败的命令，并在返回前发送剩余事件
+//
+// 返回值与 root.Execute() 相同。Execute 会关闭分析客户端。
+func (t *CommandTracker) Execute() error {
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+	cmd, err := t.root.ExecuteC()
+	if !t.recorded && cmd != nil {
+		t.record(cmd, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.opts.FlushTimeout)
+	defer cancel()
+	t.client.Shutdown(ctx)
+	return err
+}
+
+// Enabled 返回当前是否统计
+func (t *CommandTracker) Enabled() bool {
+	t.resolveConsent()
+	return t.enabled
+}
+
+// SetEnabled 保存用户的选择，并同步客户端的退出状态
+func (t *CommandTracker) SetEnabled(enabled bool) error {
+	t.enabled = enabled
+	t.decided = true
+	if enabled {
+		t.client.Resume()
+		t.client.OptIn()
+	} else {
+		t.client.OptOut()
+	}
+	return saveConsent(t.opts.ConsentFile, enabled)
+}
+
+// status 返回当前是否统计，尚未做出选择时不询问
+func (t *CommandTracker) status() bool {
+	if t.decided {
+		return t.enabled
+	}
+	return t.decide(false)
+}
+
+// Command 返回管理统计授权的子命令：telemetry [enable|disable|status]
+func (t *CommandTracker) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Manage anonymous usage statistics",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "enable",
+			Short: "Enable anonymous usage statistics",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return t.SetEnabled(true)
+			},
+		},
+		&cobra.Command{
+			Use:   "disable",
+			Short: "Disable anonymous usage statistics",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return t.SetEnabled(false)
+			},
+		},
+		&cobra.Command{
+			Use:   "status",
+			Short: "Show whether usage statistics are enabled",
+			Run: func(cmd *cobra.Command, args []string) {
+				state := "disabled"
+				if t.status() {
+					state = "enabled"
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), "telemetry is", state)
+			},
+		},
+	)
+	return cmd
+}
+
+// resolveConsent 按 环境变量 > 已保存的选择 > 询问 > 默认值 的顺序决定是否统计，
+// 不统计时暂停客户端，崩溃与安装信息也不会发送。暂停不会写入客户端的授权文件，
+// 移除环境变量后下次运行即恢复
+func (t *CommandTracker) resolveConsent() {
+	if t.decided {
+		return
+	}
+	t.decided = true
+	t.enabled = t.decide(true)
+	if !t.enabled {
+		t.client.Suspend()
+	}
+}
+
+// decide 返回是否统计，allowPrompt 为 false 时不询问用户
+func (t *CommandTracker) decide(allowPrompt bool) bool {
+	if analytics.EnvOptOut("DO_NOT_TRACK", t.opts.EnvVar) {
+		return false
+	}
+	if state := loadConsent(t.opts.ConsentFile); state != nil {
+		return state.Enabled
+	}
+	if allowPrompt && t.opts.Prompt && t.interactive() {
+		enabled := prompt(t.opts.In, t.opts.Out, t.opts.PromptMessage)
+		saveConsent(t.opts.ConsentFile, enabled)
+		return enabled
+	}
+	return t.opts.DefaultEnabled
+}
+
+// interactive 判断是否可以询问用户
+func (t *CommandTracker) interactive() bool {
+	if f, ok := t.opts.In.(*os.File); ok {
+		return isTerminal(f)
+	}
+	return true
+}
+
+// record 记录一次命令执行
+func (t *CommandTracker) record(cmd *cobra.Command, err error) {
+	t.recorded = true
+	if isTelemetryCommand(cmd) {
+		return
+	}
+	t.resolveConsent()
+	if !t.enabled {
+		return
+	}
+
+	flags := make([]string, 0)
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		flags = append(flags, f.Name)
+	})
+	sort.Strings(flags)
+
+	status, code := "success", 0
+	if err != nil {
+		status, code = "error", 1
+	}
+
+	t.client.Track(t.opts.EventName, map[string]interface{}{
+		"command":     cmd.CommandPath(),
+		"flags":       flags,
+		"duration_ms": time.Since(t.start).Milliseconds(),
+		"exit_status": status,
+		"exit_code":   code,
+	})
+}
+
+// isTelemetryCommand 授权管理命令本身不记录
+func isTelemetryCommand(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Name() == "telemetry" && c.HasParent() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package analyticscobra
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	analytics "github.com/difyz9/go-analysis-client"
+	"github.com/spf13/cobra"
+)
+
+// collect 启动事件收集服务器，返回读取已收到事件的函数
+func collect(t *testing.T) (string, func() []analytics.Event) {
+	t.Helper()
+	var mu sync.Mutex
+	var events []analytics.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []analytics.Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		events = append(events, payload.Events...)
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, func() []analytics.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]analytics.Event(nil), events...)
+	}
+}
+
+// newRoot 构建测试用命令树
+func newRoot(preRan *bool) *cobra.Command {
+	root := &cobra.Command{
+		Use: "mycli",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			*preRan = true
+		},
+	}
+	deploy := &cobra.Command{
+		Use:  "deploy",
+		RunE: func(cmd *cobra.Command, args []string) error { return nil },
+	}
+	deploy.Flags().String("env", "", "")
+	deploy.Flags().Bool("force", false, "")
+	deploy.Flags().Int("replicas", 1, "")
+	fail := &cobra.Command{
+		Use:  "fail",
+		RunE: func(cmd *cobra.Command, args []string) error { return errors.New("boom") },
+	}
+	root.AddCommand(deploy, fail)
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+	return root
+}
+
+func run(t *testing.T, opts Options, args ...string) ([]analytics.Event, bool, error) {
+	t.Helper()
+	url, events := collect(t)
+	client := analytics.NewClient(url, "mycli")
+
+	preRan := false
+	root := newRoot(&preRan)
+	root.SetArgs(args)
+	tracker := Instrument(root, client, &opts)
+	root.AddCommand(tracker.Command())
+	err := tracker.Execute()
+	return events(), preRan, err
+}
+
+func TestCommandRun(t *testing.T) {
+	t.Setenv("DO_NOT_TRACK", "")
+	consent := filepath.Join(t.TempDir(), "telemetry.json")
+
+	events, preRan, err := run(t, Options{ConsentFile: consent, DefaultEnabled: true},
+		"deploy", "--env=prod-secret", "--force")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !preRan {
+		t.Error("existing PersistentPreRun was not called")
+	}
+	if len(events) != 1 || events[0].Name != DefaultEventName {
+		t.Fatalf("events = %+v", events)
+	}
+	p := events[0].Properties
+	if p["command"] != "mycli deploy" || p["exit_status"] != "success" || p["exit_code"] != float64(0) {
+		t.Errorf("properties = %v", p)
+	}
+	flags, _ := json.Marshal(p["flags"])
+	if string(flags) != `["env","force"]` {
+		t.Errorf("flags = %s", flags)
+	}
+	if strings.Contains(string(mustJSON(t, p)), "prod-secret") {
+		t.Error("flag values must not be recorded")
+	}
+
+	events, _, err = run(t, Options{ConsentFile: consent, DefaultEnabled: true}, "fail")
+	if err == nil || len(events) != 1 || events[0].Properties["exit_status"] != "error" {
+		t.Errorf("failed command: err=%v events=%+v", err, events)
+	}
+}
+
+func TestOptOut(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"DO_NOT_TRACK", map[string]string{"DO_NOT_TRACK": "1"}},
+		{"产品环境变量", map[string]string{"DO_NOT_TRACK": "", "MYCLI_NO_TELEMETRY": "true"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			consent := filepath.Join(t.TempDir(), "telemetry.json")
+			events, _, _ := run(t, Options{ConsentFile: consent, DefaultEnabled: true, EnvVar: "MYCLI_NO_TELEMETRY"}, "deploy")
+			if len(events) != 0 {
+				t.Errorf("events sent despite opt-out: %+v", events)
+			}
+		})
+	}
+}
+
+// TestEnvOptOutNotPersisted 测试环境变量关闭统计时不会持久化客户端的退出状态
+func TestEnvOptOutNotPersisted(t *testing.T) {
+	t.Setenv("DO_NOT_TRACK", "1")
+	url, events := collect(t)
+	clientConsent := filepath.Join(t.TempDir(), "client.json")
+	client := analytics.NewClient(url, "mycli", analytics.WithConsentFile(clientConsent))
+
+	preRan := false
+	root := newRoot(&preRan)
+	root.SetArgs([]string{"deploy"})
+	tracker := Instrument(root, client, &Options{ConsentFile: filepath.Join(t.TempDir(), "telemetry.json"), DefaultEnabled: true})
+	tracker.Execute()
+	if got := events(); len(got) != 0 {
+		t.Errorf("events sent despite DO_NOT_TRACK: %+v", got)
+	}
+
+	// 移除环境变量后恢复统计
+	t.Setenv("DO_NOT_TRACK", "")
+	client = analytics.NewClient(url, "mycli", analytics.WithConsentFile(clientConsent))
+	defer client.Close()
+	if client.IsOptedOut() {
+		t.Error("DO_NOT_TRACK opt-out was persisted to the client consent file")
+	}
+}
+
+func TestConsentPrompt(t *testing.T) {
+	t.Setenv("DO_NOT_TRACK", "")
+	consent := filepath.Join(t.TempDir(), "telemetry.json")
+
+	var out bytes.Buffer
+	events, _, _ := run(t, Options{ConsentFile: consent, Prompt: true, In: strings.NewReader("n\n"), Out: &out}, "deploy")
+	if len(events) != 0 {
+		t.Errorf("events sent after declining: %+v", events)
+	}
+	if !strings.Contains(out.String(), "[Y/n]") {
+		t.Errorf("prompt not shown: %q", out.String())
+	}
+
+	// 已保存的选择不再询问
+	out.Reset()
+	events, _, _ = run(t, Options{ConsentFile: consent, Prompt: true, In: strings.NewReader("y\n"), Out: &out}, "deploy")
+	if len(events) != 0 || out.Len() != 0 {
+		t.Errorf("saved choice ignored: events=%d prompt=%q", len(events), out.String())
+	}
+
+	// telemetry enable 修改保存的选择，且该命令本身不记录
+	events, _, _ = run(t, Options{ConsentFile: consent}, "telemetry", "enable")
+	if len(events) != 0 {
+		t.Errorf("telemetry command was recorded: %+v", events)
+	}
+	events, _, _ = run(t, Options{ConsentFile: consent}, "deploy")
+	if len(events) != 1 {
+		t.Errorf("events after enable = %d, want 1", len(events))
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestTelemetryCommandsDoNotPrompt 测试 telemetry 子命令不触发首次运行询问
+func TestTelemetryCommandsDoNotPrompt(t *testing.T) {
+	t.Setenv("DO_NOT_TRACK", "")
+	consent := filepath.Join(t.TempDir(), "telemetry.json")
+
+	for _, args := range [][]string{{"telemetry", "status"}, {"telemetry", "disable"}} {
+		var out bytes.Buffer
+		run(t, Options{ConsentFile: consent, Prompt: true, In: strings.NewReader("y\n"), Out: &out}, args...)
+		if out.Len() != 0 {
+			t.Errorf("%v prompted: %q", args, out.String())
+		}
+	}
+	if state := loadConsent(consent); state == nil || state.Enabled {
+		t.Errorf("consent after disable = %+v, want disabled", state)
+	}
+}
@@ -0,0 +1,75 @@
+package analyticscobra
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// consentState 持久化的授权状态
+type consentState struct {
+	Enabled   bool      `json:"enabled"`
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// loadConsent 读取持久化的授权状态，不存在时返回 nil
+func loadConsent(path string) *consentState {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var state consentState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil
+	}
+	return &state
+}
+
+// saveConsent 持久化授权状态
+func saveConsent(path string, enabled bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(consentState{Enabled: enabled, DecidedAt: time.Now().UTC()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// defaultConsentFile 返回默认的授权文件路径：<UserConfigDir>/<product>/telemetry.json
+func defaultConsentFile(product string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, product, "telemetry.json")
+}
+
+// prompt 询问用户是否允许统计，空回答视为同意
+func prompt(in io.Reader, out io.Writer, message string) bool {
+	fmt.Fprint(out, message)
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "", "y", "yes":
+		return true
+	}
+	return false
+}
+
+// isTerminal 判断文件是否为交互式终端
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,26 @@
+module github.com/difyz9/go-analysis-client/cobra
+
+go 1.23.0
+
+require (
+	github.com/difyz9/go-analysis-client v1.2.0
+	github.com/spf13/cobra v1.8.1
+	github.com/spf13/pflag v1.0.5
+)
+
+require (
+	github.com/ebitengine/purego v0.9.0 // indirect
+	github.com/go-ole/go-ole v1.2.6 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/lufia/plan9stats v0.0.0-20211012122336-39d0f177ccd0 // indirect
+	github.com/power-devops/perfstat v0.0.0-20240221224432-82ca36839d55 // indirect
+	github.com/shirou/gopsutil/v4 v4.25.9 // indirect
+	github.com/tklauser/go-sysconf v0.3.15 // indirect
+	github.com/tklauser/numcpus v0.10.0 // indirect
+	github.com/yusufpapurcu/wmi v1.2.4 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+)
+
+// 本地开发使用仓库中的核心模块；作为依赖被引用时该指令不生效，使用上面 require 的版本
+replace github.com/difyz9/go-analysis-client => ../
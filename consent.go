@@ -0,0 +1,300 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 用户授权与退出统计
+// =============================================================================
+
+// 授权类别
+const (
+	// ConsentEssential 必要事件，只要未退出统计就会发送
+	ConsentEssential = "essential"
+
+	// ConsentUsage 使用统计，Track 发送的事件默认属于该类别
+	ConsentUsage = "usage"
+
+	// ConsentCrash 崩溃与错误报告
+	ConsentCrash = "crash"
+)
+
+// consentFileState 持久化的授权状态
+type consentFileState struct {
+	OptedOut   bool      `json:"opted_out"`
+	Categories []string  `json:"categories,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// consentManager 管理退出状态与已授权的类别
+type consentManager struct {
+	mu         sync.RWMutex
+	optedOut   bool
+	envOptOut  bool
+	suspended  bool // 仅本进程内暂停，不持久化
+	categories map[string]bool // nil 表示授权所有类别
+	file       string
+	envVars    []string
+}
+
+// WithConsentFile 设置授权状态的持久化文件
+//
+// 文件存在时，NewClient 会从中恢复退出状态与授权类别；
+// OptOut、OptIn 与 SetConsent 会写回该文件。未设置时状态仅保存在内存中。
+func WithConsentFile(path string) ClientOption {
+	return func(c *Client) {
+		c.consent.file = path
+	}
+}
+
+// WithOptOutEnvVar 设置产品自定义的退出统计环境变量，例如 "MYAPP_NO_TELEMETRY"
+//
+// 与 DO_NOT_TRACK 一样，取值为 1/true/yes/on 时退出统计，且优先于 OptIn。
+func WithOptOutEnvVar(name string) ClientOption {
+	return func(c *Client) {
+		c.consent.envVars = append(c.consent.envVars, name)
+	}
+}
+
+// WithConsent 设置默认授权的类别（持久化文件中已有选择时以文件为准）
+//
+// 未设置时授权所有类别。ConsentEssential 总是被授权。
+func WithConsent(categories ...string) ClientOption {
+	return func(c *Client) {
+		c.consent.categories = categorySet(categories)
+	}
+}
+
+// load 读取环境变量与持久化状态
+func (m *consentManager) load() {
+	m.envOptOut = EnvOptOut(append([]string{"DO_NOT_TRACK"}, m.envVars...)...)
+
+	if m.file == "" {
+		return
+	}
+	data, err := os.ReadFile(m.file)
+	if err != nil {
+		return
+	}
+	var state consentFileState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return
+	}
+	m.optedOut = state.OptedOut
+	if state.Categories != nil {
+		m.categories = categorySet(state.Categories)
+	}
+}
+
+// save 持久化当前状态（调用方需持有写锁）
+func (m *consentManager) save() error {
+	if m.file == "" {
+		return nil
+	}
+	state := consentFileState{OptedOut: m.optedOut, UpdatedAt: time.Now().UTC()}
+	if m.categories != nil {
+		state.Categories = make([]string, 0, len(m.categories))
+		for cat := range m.categories {
+			state.Categories = append(state.Categories, cat)
+		}
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return newClientError("saveConsent", fmt.Errorf("%w: %v", ErrMarshalFailed, err))
+	}
+	if err := os.MkdirAll(filepath.Dir(m.file), 0o755); err != nil {
+		return newClientError("saveConsent", err)
+	}
+	if err := os.WriteFile(m.file, data, 0o600); err != nil {
+		return newClientError("saveConsent", err)
+	}
+	return nil
+}
+
+// disabled 是否完全退出统计
+func (m *consentManager) disabled() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.envOptOut || m.optedOut || m.suspended
+}
+
+// allows 判断类别是否被授权
+func (m *consentManager) allows(category string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.envOptOut || m.optedOut || m.suspended {
+		return false
+	}
+	if category == "" {
+		category = ConsentUsage
+	}
+	if category == ConsentEssential || m.categories == nil {
+		return true
+	}
+	return m.categories[category]
+}
+
+// OptOut 退出统计
+//
+// 立即丢弃队列中尚未发送的事件，之后的事件、安装上报与崩溃上报都不会发送。
+// 配置了 WithConsentFile 时状态会被持久化。
+func (c *Client) OptOut() error {
+	c.consent.mu.Lock()
+	c.consent.optedOut = true
+	err := c.consent.save()
+	c.consent.mu.Unlock()
+
+	purged := c.purgeQueue()
+	if c.debug && c.logger != nil {
+		c.logger.Printf("[Analytics] Opted out, purged %d queued events", purged)
+	}
+	c.logAttrs(slog.LevelInfo, "analytics: opted out", slog.Int("purged", purged))
+	return err
+}
+
+// OptIn 重新加入统计
+//
+// 若设置了 DO_NOT_TRACK 或 WithOptOutEnvVar 指定的环境变量，环境变量优先，仍不会发送。
+func (c *Client) OptIn() error {
+	c.consent.mu.Lock()
+	defer c.consent.mu.Unlock()
+	c.consent.optedOut = false
+	return c.consent.save()
+}
+
+// Suspend 在本进程内暂停统计，不写入 WithConsentFile 设置的文件
+//
+// 与 OptOut 一样丢弃队列中尚未发送的事件。适用于由环境或宿主程序决定不统计、
+// 而不是用户做出选择的场景，例如命令行工具检测到 DO_NOT_TRACK。
+func (c *Client) Suspend() {
+	c.consent.mu.Lock()
+	c.consent.suspended = true
+	c.consent.mu.Unlock()
+
+	purged := c.purgeQueue()
+	c.logAttrs(slog.LevelInfo, "analytics: suspended", slog.Int("purged", purged))
+}
+
+// Resume 取消 Suspend，退出状态与环境变量仍然优先
+func (c *Client) Resume() {
+	c.consent.mu.Lock()
+	defer c.consent.mu.Unlock()
+	c.consent.suspended = false
+}
+
+// IsOptedOut 返回是否已退出统计（包括环境变量与 Suspend）
+func (c *Client) IsOptedOut() bool {
+	return c.consent.disabled()
+}
+
+// SetConsent 设置授权的类别并持久化，未授权类别中已在队列中的事件会被丢弃
+func (c *Client) SetConsent(categories ...string) error {
+	c.consent.mu.Lock()
+	c.consent.categories = categorySet(categories)
+	err := c.consent.save()
+	c.consent.mu.Unlock()
+
+	c.purgeQueue()
+	return err
+}
+
+// HasConsent 返回指定类别是否被授权
+func (c *Client) HasConsent(category string) bool {
+	return c.consent.allows(category)
+}
+
+// TrackWithConsent 发送属于指定授权类别的事件（异步）
+//
+//	client.TrackWithConsent(analytics.ConsentEssential, "license_check", nil)
+func (c *Client) TrackWithConsent(category, eventName string, properties map[string]interface{}) {
+	e := newEvent()
+	e.Name = eventName
+	e.Timestamp = time.Now().Unix()
+	e.Properties = properties
+	e.Consent = category
+	c.enqueue(e)
+}
+
+// purgeQueue 丢弃队列中不可发送的事件，返回丢弃数量
+func (c *Client) purgeQueue() int {
+	purged := 0
+	var keep []*Event
+	for {
+		select {
+		case event := <-c.events:
+			if c.sendable(event) {
+				keep = append(keep, event)
+			} else {
+				releaseEvent(event)
+				purged++
+			}
+			continue
+		default:
+		}
+		break
+	}
+	for _, event := range keep {
+		select {
+		case c.events <- event:
+		default:
+		}
+	}
+	return purged
+}
+
+// filterSendable 过滤掉不可发送的事件
+func (c *Client) filterSendable(events []*Event) []*Event {
+	allowed := events[:0:0]
+	for _, e := range events {
+		if c.sendable(e) {
+			allowed = append(allowed, e)
+		} else {
+			releaseEvent(e)
+		}
+	}
+	return allowed
+}
+
+// sendable 判断事件是否被授权，且不是在 RequestDeletion 之前入队的
+func (c *Client) sendable(e *Event) bool {
+	if e.generation != 0 && e.generation != c.generation.Load()+1 {
+		return false
+	}
+	return c.consent.allows(e.Consent)
+}
+
+// categorySet 将类别列表转换为集合
+func categorySet(categories []string) map[string]bool {
+	set := make(map[string]bool, len(categories))
+	for _, cat := range categories {
+		set[cat] = true
+	}
+	return set
+}
+
+// EnvOptOut 判断环境变量是否要求退出统计，取值为 1/true/yes/on（不区分大小写）时退出
+//
+// 客户端总是检查 DO_NOT_TRACK（https://consoledonottrack.com）与 WithOptOutEnvVar 指定的变量，
+// 集成包可用它检查同样的约定：
+//
+//	analytics.EnvOptOut("DO_NOT_TRACK", "MYAPP_NO_TELEMETRY")
+func EnvOptOut(names ...string) bool {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(os.Getenv(name))) {
+		case "1", "true", "yes", "on":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package analytics
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestOptOutPurgesQueue 测试退出统计时丢弃队列中的事件
+func TestOptOutPurgesQueue(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp", WithFlushInterval(time.Hour))
+
+	client.Track("queued", nil)
+	if err := client.OptOut(); err != nil {
+		t.Fatalf("OptOut: %v", err)
+	}
+	client.Track("after_opt_out", nil)
+	client.TrackError(errors.New("boom"), nil)
+	client.Close()
+
+	if events := server.Events(); len(events) != 0 {
+		t.Errorf("received %d events after opt-out, want 0", len(events))
+	}
+	if !client.IsOptedOut() {
+		t.Error("IsOptedOut() = false after OptOut")
+	}
+}
+
+// TestConsentFilePersistence 测试授权状态的持久化
+func TestConsentFilePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "consent.json")
+	server := newEventCollector(t)
+
+	first := NewClient(server.URL, "TestApp", WithConsentFile(path))
+	if err := first.OptOut(); err != nil {
+		t.Fatalf("OptOut: %v", err)
+	}
+	first.Close()
+
+	second := NewClient(server.URL, "TestApp", WithConsentFile(path))
+	if !second.IsOptedOut() {
+		t.Fatal("opt-out was not restored from consent file")
+	}
+	if err := second.OptIn(); err != nil {
+		t.Fatalf("OptIn: %v", err)
+	}
+	second.Track("back", nil)
+	second.Close()
+
+	third := NewClient(server.URL, "TestApp", WithConsentFile(path))
+	defer third.Close()
+	if third.IsOptedOut() {
+		t.Error("opt-in was not restored from consent file")
+	}
+	if events := server.Events(); len(events) != 1 || events[0].Name != "back" {
+		t.Errorf("events = %+v, want only \"back\"", events)
+	}
+}
+
+// TestConsentEnvVar 测试 DO_NOT_TRACK 与产品环境变量优先于 OptIn
+func TestConsentEnvVar(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   string
+		value string
+	}{
+		{"do not track", "DO_NOT_TRACK", "1"},
+		{"product var", "MYAPP_NO_TELEMETRY", "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+			client := NewClient("http://127.0.0.1:0", "TestApp", WithOptOutEnvVar("MYAPP_NO_TELEMETRY"))
+			defer client.Close()
+
+			client.OptIn()
+			if !client.IsOptedOut() {
+				t.Errorf("%s=%s did not opt out", tt.env, tt.value)
+			}
+		})
+	}
+}
+
+// TestSuspendNotPersisted 测试 Suspend 丢弃事件但不写入授权文件
+func TestSuspendNotPersisted(t *testing.T) {
+	server := newEventCollector(t)
+	path := filepath.Join(t.TempDir(), "consent.json")
+	client := NewClient(server.URL, "TestApp", WithFlushInterval(time.Hour), WithConsentFile(path))
+
+	client.Track("queued", nil)
+	client.Suspend()
+	client.Track("suspended", nil)
+	if !client.IsOptedOut() {
+		t.Error("IsOptedOut() = false after Suspend")
+	}
+	client.Resume()
+	client.Track("resumed", nil)
+	client.Close()
+
+	if events := server.Events(); len(events) != 1 || events[0].Name != "resumed" {
+		t.Errorf("events = %+v, want only resumed", events)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("consent file written by Suspend: %v", err)
+	}
+}
+
+// TestConsentCategories 测试按授权类别过滤事件
+func TestConsentCategories(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp",
+		WithFlushInterval(time.Hour),
+		WithConsent(ConsentCrash),
+	)
+
+	client.Track("usage_event", nil)
+	client.TrackWithConsent(ConsentEssential, "essential_event", nil)
+	client.TrackWithConsent(ConsentCrash, "crash_event", nil)
+	client.TrackWithConsent(ConsentUsage, "usage_event_2", nil)
+
+	// 撤销崩溃报告授权后，队列中的崩溃事件也被丢弃
+	client.TrackWithConsent(ConsentCrash, "revoked_crash_event", nil)
+	if err := client.SetConsent(); err != nil {
+		t.Fatalf("SetConsent: %v", err)
+	}
+	client.Close()
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Name != "essential_event" {
+		t.Fatalf("events = %+v, want only essential_event", events)
+	}
+	if events[0].Consent != ConsentEssential {
+		t.Errorf("consent = %q, want %q", events[0].Consent, ConsentEssential)
+	}
+	if client.HasConsent(ConsentUsage) || !client.HasConsent(ConsentEssential) {
+		t.Error("HasConsent returned unexpected result")
+	}
+}
@@ -0,0 +1,143 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// =============================================================================
+// Context 传递
+// =============================================================================
+
+// ContextExtractor 从 context 中提取事件属性
+//
+// 返回的属性会合并到通过 TrackContext 发送的事件中，
+// 但不会覆盖调用方显式传入的同名属性。
+type ContextExtractor func(ctx context.Context) map[string]interface{}
+
+// contextKey 包内 context key 类型，避免与其他包冲突
+type contextKey int
+
+const (
+	propertiesContextKey contextKey = iota
+)
+
+// WithContextExtractor 注册 context 属性提取器
+//
+//	analytics.WithContextExtractor(
+//	    analytics.ContextValueExtractor(tenantKey{}, "tenant_id"),
+//	)
+func WithContextExtractor(extractors ...ContextExtractor) ClientOption {
+	return func(c *Client) {
+		c.extractors = append(c.extractors, extractors...)
+	}
+}
+
+// ContextValueExtractor 返回一个读取 ctx.Value(key) 并写入 property 属性的提取器
+//
+// 值为 nil 时不写入；实现 fmt.Stringer 的值（如自定义 ID 类型）写入 String() 的结果，
+// 其他值原样保留，由入队时的规范化处理。
+func ContextValueExtractor(key interface{}, property string) ContextExtractor {
+	return func(ctx context.Context) map[string]interface{} {
+		v := ctx.Value(key)
+		if v == nil {
+			return nil
+		}
+		if s, ok := v.(fmt.Stringer); ok {
+			v = s.String()
+		}
+		return map[string]interface{}{property: v}
+	}
+}
+
+// ContextWithProperties 返回携带事件属性的 context
+//
+// 适用于在请求入口（如 HTTP 中间件）设置用户 ID、追踪 ID、租户等请求级属性，
+// 下游的 TrackContext 调用会自动带上这些属性。多次调用会合并属性，后设置的优先。
+func ContextWithProperties(ctx context.Context, properties map[string]interface{}) context.Context {
+	merged := make(map[string]interface{}, len(properties))
+	for k, v := range PropertiesFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range properties {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, propertiesContextKey, merged)
+}
+
+// PropertiesFromContext 返回 ContextWithProperties 设置的属性
+func PropertiesFromContext(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	props, _ := ctx.Value(propertiesContextKey).(map[string]interface{})
+	return props
+}
+
+// TrackContext 发送事件（异步），并附加从 context 中提取的属性
+//
+//	ctx = analytics.ContextWithProperties(ctx, map[string]interface{}{"tenant_id": "t-1"})
+//	client.TrackContext(ctx, "report_export", map[string]interface{}{"format": "csv"})
+func (c *Client) TrackContext(ctx context.Context, eventName string, properties map[string]interface{}) {
+	c.Track(eventName, c.contextProperties(ctx, properties))
+}
+
+// contextProperties 合并 context 属性与显式属性，返回新的属性 map
+func (c *Client) contextProperties(ctx context.Context, properties map[string]interface{}) map[string]interface{} {
+	if ctx == nil {
+		return properties
+	}
+
+	merged := make(map[string]interface{})
+	for k, v := range PropertiesFromContext(ctx) {
+		merged[k] = v
+	}
+	for _, extract := range c.extractors {
+		for k, v := range extract(ctx) {
+			merged[k] = v
+		}
+	}
+	if len(merged) == 0 {
+		return properties
+	}
+	for k, v := range properties {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Shutdown 关闭客户端，在 ctx 结束前尽量发送所有剩余事件
+//
+// 若 ctx 在剩余事件发送完成前结束，正在进行的请求会被取消，并返回 ctx.Err()。
+// 启用熔断器时，熔断中未能补发的暂存事件被丢弃，返回包含 ErrCircuitOpen 的错误。
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.closeOnce.Do(func() {
+		// 汇总最后一个周期的限流统计，随剩余事件一起发送
+		if c.limiter != nil {
+			c.reportRateLimited()
+		}
+		close(c.quit)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		c.cancel()
+		return c.heldDroppedError()
+	case <-ctx.Done():
+		c.cancel()
+		<-done
+		return errors.Join(ctx.Err(), c.heldDroppedError())
+	}
+}
+
+// ReportInstallContext 同步上报安装信息，请求随 ctx 取消
+func (c *Client) ReportInstallContext(ctx context.Context) error {
+	return c.reportInstallSync(ctx)
+}
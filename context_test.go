@@ -0,0 +1,149 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type tenantKey struct{}
+
+// TestTrackContext 测试 context 属性与提取器的合并
+func TestTrackContext(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp",
+		WithContextExtractor(ContextValueExtractor(tenantKey{}, "tenant_id")),
+	)
+
+	ctx := ContextWithProperties(context.Background(), map[string]interface{}{
+		"user_id":  "u-1",
+		"trace_id": "t-1",
+	})
+	ctx = ContextWithProperties(ctx, map[string]interface{}{"trace_id": "t-2"})
+	ctx = context.WithValue(ctx, tenantKey{}, "acme")
+
+	props := map[string]interface{}{"user_id": "explicit"}
+	client.TrackContext(ctx, "ctx_event", props)
+	client.Close()
+
+	if len(props) != 1 {
+		t.Errorf("caller's properties were mutated: %v", props)
+	}
+
+	events := server.Events()
+	if len(events) != 1 {
+		t.Fatalf("received %d events, want 1", len(events))
+	}
+	want := map[string]interface{}{
+		"user_id":   "explicit",
+		"trace_id":  "t-2",
+		"tenant_id": "acme",
+	}
+	for k, v := range want {
+		if events[0].Properties[k] != v {
+			t.Errorf("property %s = %v, want %v", k, events[0].Properties[k], v)
+		}
+	}
+}
+
+// TestContextValueExtractor 测试 fmt.Stringer 转换为字符串，其他值原样保留
+func TestContextValueExtractor(t *testing.T) {
+	extract := ContextValueExtractor(tenantKey{}, "v")
+	tests := []struct {
+		value interface{}
+		want  interface{}
+	}{
+		{1500 * time.Millisecond, "1.5s"},
+		{42, 42},
+		{"acme", "acme"},
+	}
+	for _, tt := range tests {
+		got := extract(context.WithValue(context.Background(), tenantKey{}, tt.value))
+		if got["v"] != tt.want {
+			t.Errorf("extract(%#v) = %#v, want %#v", tt.value, got["v"], tt.want)
+		}
+	}
+	if got := extract(context.Background()); got != nil {
+		t.Errorf("extract(nil) = %v, want nil", got)
+	}
+}
+
+// TestShutdownCancelsInFlight 测试 Shutdown 超时后取消正在进行的请求
+func TestShutdownCancelsInFlight(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(server.URL, "TestApp", WithTimeout(time.Minute))
+	client.Track("slow", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := client.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Shutdown() took %v, in-flight request was not cancelled", elapsed)
+	}
+
+	// 重复关闭不应 panic
+	if err := client.Close(); err != nil {
+		t.Errorf("Close() after Shutdown error = %v", err)
+	}
+}
+
+// TestReportInstallContextCancelled 测试已取消的 context 不会发送请求
+func TestReportInstallContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not be sent")
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "TestApp")
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := client.ReportInstallContext(ctx)
+	if err == nil {
+		t.Fatal("ReportInstallContext() with cancelled context should fail")
+	}
+	if isRetryableError(err) {
+		t.Error("cancelled request should not be retryable")
+	}
+}
+
+// TestAESClientContext 测试 AESClient 的请求可以通过 ctx 取消
+func TestAESClientContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewAESClient(server.URL, "0123456789abcdef0123456789abcdef")
+	for name, post := range map[string]func(context.Context) ([]byte, error){
+		"encrypted": func(ctx context.Context) ([]byte, error) { return client.PostEncryptedContext(ctx, "/", nil) },
+		"plain":     func(ctx context.Context) ([]byte, error) { return client.PostPlainContext(ctx, "/", nil) },
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err := post(ctx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: error = %v, want context.DeadlineExceeded", name, err)
+		}
+	}
+}
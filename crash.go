@@ -0,0 +1,127 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// =============================================================================
+// Panic / 崩溃上报
+// =============================================================================
+
+// CrashEventName 崩溃事件名称
+const CrashEventName = "app_crash"
+
+// WithRepanic 设置上报崩溃后是否重新 panic，默认为 true
+//
+// 设置为 false 时，RecoverAndReport 会吞掉 panic，程序继续运行
+// （对 Go 启动的 goroutine 而言，该 goroutine 正常退出）。
+func WithRepanic(repanic bool) ClientOption {
+	return func(c *Client) {
+		c.repanic = repanic
+	}
+}
+
+// WithCrashTimeout 设置同步发送崩溃事件的超时时间，默认为 5 秒
+func WithCrashTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.crashTimeout = timeout
+	}
+}
+
+// RecoverAndReport 捕获 panic 并同步上报 app_crash 事件
+//
+// 必须通过 defer 直接调用：
+//
+//	func main() {
+//	    client := analytics.NewClient(url, "MyApp")
+//	    defer client.Close()
+//	    defer client.RecoverAndReport()
+//	    ...
+//	}
+//
+// 崩溃事件绕过批量发送直接上报，包含 panic 信息、堆栈、Go 版本与模块构建信息。
+// 上报完成后，若启用了 WithRepanic（默认），将以原始值重新 panic。
+func (c *Client) RecoverAndReport() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	c.reportPanic(r, debug.Stack())
+	if c.repanic {
+		panic(r)
+	}
+}
+
+// Go 在新的 goroutine 中运行 fn，并捕获上报其中的 panic
+//
+//	client.Go(func() {
+//	    processJob(job)
+//	})
+func (c *Client) Go(fn func()) {
+	go func() {
+		defer c.RecoverAndReport()
+		fn()
+	}()
+}
+
+// reportPanic 构建并同步发送崩溃事件
+func (c *Client) reportPanic(r interface{}, stack []byte) {
+	properties := crashProperties(r, stack)
+	id := c.currentIdentity()
+	properties["session_id"] = id.sessionID
+	properties["session_duration"] = time.Since(id.sessionStarted).Seconds()
+
+	event := &Event{
+		Name:       CrashEventName,
+		Timestamp:  time.Now().Unix(),
+		Properties: properties,
+		Consent:    ConsentCrash,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.crashTimeout)
+	defer cancel()
+
+	if err := c.sendEvents(ctx, []*Event{event}); err != nil {
+		if c.debug && c.logger != nil {
+			c.logger.Printf("[Analytics] Failed to report crash: %v", err)
+		}
+		c.logAttrs(slog.LevelError, "analytics: report crash failed", slog.Any("error", err))
+	}
+}
+
+// crashProperties 收集 panic、堆栈与构建信息
+func crashProperties(r interface{}, stack []byte) map[string]interface{} {
+	props := map[string]interface{}{
+		"panic":      fmt.Sprint(r),
+		"panic_type": fmt.Sprintf("%T", r),
+		"stack":      string(stack),
+		"go_version": runtime.Version(),
+		"goos":       runtime.GOOS,
+		"goarch":     runtime.GOARCH,
+		"goroutines": runtime.NumGoroutine(),
+	}
+	if err, ok := r.(error); ok {
+		props["error"] = err.Error()
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		props["main_module"] = info.Main.Path
+		props["main_version"] = info.Main.Version
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				props["vcs_revision"] = s.Value
+			case "vcs.time":
+				props["vcs_time"] = s.Value
+			case "vcs.modified":
+				props["vcs_modified"] = s.Value == "true"
+			}
+		}
+	}
+	return props
+}
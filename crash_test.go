@@ -0,0 +1,82 @@
+package analytics
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRecoverAndReport 测试 panic 被同步上报且不重新 panic
+func TestRecoverAndReport(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp", WithRepanic(false), WithFlushInterval(time.Hour))
+	defer client.Close()
+
+	func() {
+		defer client.RecoverAndReport()
+		panic(errors.New("boom"))
+	}()
+
+	// 同步发送，无需等待刷新
+	events := server.Events()
+	if len(events) != 1 || events[0].Name != CrashEventName {
+		t.Fatalf("events = %+v, want one %s", events, CrashEventName)
+	}
+	props := events[0].Properties
+	if props["panic"] != "boom" || props["error"] != "boom" || props["panic_type"] != "*errors.errorString" {
+		t.Errorf("unexpected panic properties: %v", props)
+	}
+	if props["go_version"] != runtime.Version() {
+		t.Errorf("go_version = %v", props["go_version"])
+	}
+	if !strings.Contains(props["stack"].(string), "TestRecoverAndReport") {
+		t.Error("stack does not contain the panicking function")
+	}
+}
+
+// TestRecoverAndReportRepanic 测试默认重新 panic
+func TestRecoverAndReportRepanic(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp")
+	defer client.Close()
+
+	var recovered interface{}
+	func() {
+		defer func() { recovered = recover() }()
+		defer client.RecoverAndReport()
+		panic("again")
+	}()
+
+	if recovered != "again" {
+		t.Errorf("recovered = %v, want again", recovered)
+	}
+	if len(server.Events()) != 1 {
+		t.Errorf("crash event not reported")
+	}
+}
+
+// TestGo 测试 goroutine 中的 panic 被上报
+func TestGo(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp", WithRepanic(false))
+	defer client.Close()
+
+	client.Go(func() {
+		var m map[string]int
+		m["x"] = 1
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(server.Events()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	events := server.Events()
+	if len(events) != 1 {
+		t.Fatalf("received %d events, want 1", len(events))
+	}
+	if !strings.Contains(events[0].Properties["panic"].(string), "nil map") {
+		t.Errorf("panic = %v", events[0].Properties["panic"])
+	}
+}
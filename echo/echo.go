@@ -0,0 +1,88 @@
+// Package analyticsecho 为 Echo 提供分析统计中间件
+//
+//	e := echo.New()
+//	e.Use(middleware.Recover(), analyticsecho.Middleware(client, nil))
+//
+//	e.GET("/users/:id", func(c echo.Context) error {
+//	    analyticsecho.Tracker(c).Track("user_viewed", nil)
+//	    return c.String(http.StatusOK, "ok")
+//	})
+//
+// 路由模板取自 c.Path()，处理器返回的错误与 panic 都会被上报。
+// 本包是独立的 Go module，核心 SDK 不依赖 Echo。
+package analyticsecho
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	analytics "github.com/difyz9/go-analysis-client"
+	"github.com/difyz9/go-analysis-client/analyticshttp"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware 返回记录请求统计的 Echo 中间件
+//
+// 处理器返回的错误会原样返回给 Echo 的错误处理器；记录的状态码按
+// *echo.HTTPError 的 Code 推断，其他错误记为 500。
+// 处理器 panic 时会记录请求事件与错误事件后重新 panic，应将其注册在 middleware.Recover 之后。
+func Middleware(client *analytics.Client, opts *analyticshttp.Options) echo.MiddlewareFunc {
+	rec := analyticshttp.NewRecorder(client, opts)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			if rec.Excluded(c.Request()) {
+				return next(c)
+			}
+
+			start := time.Now()
+			req, tracker := rec.Begin(c.Request())
+			c.SetRequest(req)
+
+			defer func() {
+				p := recover()
+
+				res := c.Response()
+				status := res.Status
+				if err != nil && !res.Committed {
+					status = statusOf(err)
+				}
+
+				route := c.Path()
+				if route == "" {
+					route = analyticshttp.TemplatePath(req.URL.Path)
+				}
+
+				rec.Finish(tracker, analyticshttp.RequestInfo{
+					Request:  req,
+					Route:    route,
+					Status:   status,
+					Bytes:    int(res.Size),
+					Duration: time.Since(start),
+					Err:      err,
+					Panic:    p,
+				})
+				if p != nil {
+					panic(p)
+				}
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// Tracker 返回当前请求的 Tracker，事件会继承请求的用户与会话身份
+func Tracker(c echo.Context) *analyticshttp.Tracker {
+	return analyticshttp.TrackerFromContext(c.Request().Context())
+}
+
+// statusOf 推断错误对应的响应状态码
+func statusOf(err error) int {
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he.Code
+	}
+	return http.StatusInternalServerError
+}
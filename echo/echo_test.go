@@ -0,0 +1,99 @@
+package analyticsecho
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	analytics "github.com/difyz9/go-analysis-client"
+	"github.com/difyz9/go-analysis-client/analyticshttp"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// collect 启动事件收集服务器，返回读取已收到事件的函数
+func collect(t *testing.T) (string, func() []analytics.Event) {
+	t.Helper()
+	var mu sync.Mutex
+	var events []analytics.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []analytics.Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		events = append(events, payload.Events...)
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, func() []analytics.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]analytics.Event(nil), events...)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	url, events := collect(t)
+	client := analytics.NewClient(url, "TestApp")
+
+	e := echo.New()
+	e.Use(middleware.Recover())
+	e.Use(Middleware(client, &analyticshttp.Options{
+		UserFunc: func(r *http.Request) string { return r.Header.Get("X-User") },
+	}))
+	e.GET("/users/:id", func(c echo.Context) error {
+		Tracker(c).Track("user_viewed", map[string]interface{}{"id": c.Param("id")})
+		return c.String(http.StatusOK, "ok")
+	})
+	e.GET("/forbidden", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusForbidden, "no access")
+	})
+	e.GET("/fail", func(c echo.Context) error {
+		return errors.New("db unavailable")
+	})
+	e.GET("/panic", func(c echo.Context) error {
+		panic("echo panic")
+	})
+
+	for _, path := range []string{"/users/42", "/forbidden", "/fail", "/panic"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-User", "u-1")
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	client.Close()
+
+	var requests []analytics.Event
+	counts := make(map[string]int)
+	for _, ev := range events() {
+		counts[ev.Name]++
+		if ev.Name == analyticshttp.DefaultEventName {
+			requests = append(requests, ev)
+		}
+		if ev.Properties["user_id"] != "u-1" {
+			t.Errorf("%s event missing user identity: %v", ev.Name, ev.Properties)
+		}
+	}
+	if counts["user_viewed"] != 1 || counts["error"] != 3 || len(requests) != 4 {
+		t.Fatalf("event counts = %v", counts)
+	}
+
+	want := []struct {
+		route  string
+		status float64
+	}{
+		{"/users/:id", 200},
+		{"/forbidden", 403},
+		{"/fail", 500},
+		{"/panic", 500},
+	}
+	for i, w := range want {
+		p := requests[i].Properties
+		if p["route"] != w.route || p["status"] != w.status {
+			t.Errorf("request %d = route %v status %v, want %s %v", i, p["route"], p["status"], w.route, w.status)
+		}
+	}
+}
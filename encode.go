@@ -0,0 +1,390 @@
+package analytics
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// =============================================================================
+// 热路径：事件对象池与流式 JSON 编码
+// =============================================================================
+
+// eventPool 复用 Track 创建的事件对象
+var eventPool = sync.Pool{New: func() interface{} { return new(Event) }}
+
+// newEvent 从对象池取出事件
+func newEvent() *Event {
+	e := eventPool.Get().(*Event)
+	e.pooled = true
+	return e
+}
+
+// releaseEvent 将事件放回对象池，非池化的事件忽略
+func releaseEvent(e *Event) {
+	if e == nil || !e.pooled {
+		return
+	}
+	*e = Event{}
+	eventPool.Put(e)
+}
+
+// releaseEvents 释放一批事件
+func releaseEvents(events []*Event) {
+	for _, e := range events {
+		releaseEvent(e)
+	}
+}
+
+// batchPayload 发送到 /api/events/batch 的请求体
+type batchPayload struct {
+	Product   string   `json:"product"`
+	DeviceID  string   `json:"device_id"`
+	UserID    string   `json:"user_id"`
+	SessionID string   `json:"session_id"`
+	Events    []*Event `json:"events"`
+}
+
+// payloadBuffer 池化的请求体缓冲区
+//
+// 缓冲区被请求体引用期间不能复用：Transport 可能在 Do 返回后才关闭请求体，
+// 因此按引用计数，所有请求体关闭且所有者释放后才归还对象池。
+type payloadBuffer struct {
+	data []byte
+	refs atomic.Int32
+}
+
+var payloadPool = sync.Pool{New: func() interface{} { return &payloadBuffer{data: make([]byte, 0, 4096)} }}
+
+// 超过该容量的缓冲区不放回对象池，避免偶发的大批次长期占用内存
+const maxPooledPayload = 1 << 20
+
+// getPayloadBuffer 取出缓冲区，调用方持有一个引用
+func getPayloadBuffer() *payloadBuffer {
+	b := payloadPool.Get().(*payloadBuffer)
+	b.data = b.data[:0]
+	b.refs.Store(1)
+	return b
+}
+
+// release 释放一个引用
+func (b *payloadBuffer) release() {
+	if b.refs.Add(-1) == 0 && cap(b.data) <= maxPooledPayload {
+		payloadPool.Put(b)
+	}
+}
+
+// reader 返回引用缓冲区的请求体，关闭时释放引用
+func (b *payloadBuffer) reader() io.ReadCloser {
+	b.refs.Add(1)
+	return &payloadReader{buf: b}
+}
+
+// payloadReader 读取 payloadBuffer 的请求体
+//
+// Transport 可能在另一个 goroutine 中关闭请求体，加锁保证关闭后缓冲区不再被读取。
+type payloadReader struct {
+	mu     sync.Mutex
+	buf    *payloadBuffer
+	off    int
+	closed bool
+}
+
+func (r *payloadReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.off >= len(r.buf.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.buf.data[r.off:])
+	r.off += n
+	return n, nil
+}
+
+func (r *payloadReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		r.buf.release()
+	}
+	return nil
+}
+
+// appendBatch 将批次编码为 JSON，输出与 encoding/json 一致
+//
+// 无法编码的事件被跳过，不影响同批次的其他事件：它们从 p.Events 中移除并作为 bad 返回，
+// err 为第一个编码错误。
+func appendBatch(buf []byte, p *batchPayload) (_ []byte, bad []*Event, err error) {
+	buf = append(buf, `{"product":`...)
+	buf = appendString(buf, p.Product)
+	buf = append(buf, `,"device_id":`...)
+	buf = appendString(buf, p.DeviceID)
+	buf = append(buf, `,"user_id":`...)
+	buf = appendString(buf, p.UserID)
+	buf = append(buf, `,"session_id":`...)
+	buf = appendString(buf, p.SessionID)
+	buf = append(buf, `,"events":`...)
+	if p.Events == nil {
+		return append(buf, "null}"...), nil, nil
+	}
+	buf = append(buf, '[')
+	kept := p.Events[:0]
+	for _, e := range p.Events {
+		mark := len(buf)
+		if len(kept) > 0 {
+			buf = append(buf, ',')
+		}
+		var eventErr error
+		if buf, eventErr = appendEvent(buf, e); eventErr != nil {
+			buf = buf[:mark]
+			bad = append(bad, e)
+			if err == nil {
+				err = eventErr
+			}
+			continue
+		}
+		kept = append(kept, e)
+	}
+	p.Events = kept
+	return append(buf, "]}"...), bad, err
+}
+
+// appendEvent 将事件编码为 JSON，字段顺序与 omitempty 与 Event 的 json 标签一致
+func appendEvent(buf []byte, e *Event) ([]byte, error) {
+	if e == nil {
+		return append(buf, "null"...), nil
+	}
+	buf = append(buf, `{"name":`...)
+	buf = appendString(buf, e.Name)
+	buf = append(buf, `,"timestamp":`...)
+	buf = strconv.AppendInt(buf, e.Timestamp, 10)
+	if len(e.Properties) > 0 {
+		buf = append(buf, `,"properties":`...)
+		var err error
+		if buf, err = appendMap(buf, e.Properties); err != nil {
+			return buf, err
+		}
+	}
+	if e.Category != "" {
+		buf = append(buf, `,"category":`...)
+		buf = appendString(buf, e.Category)
+	}
+	if e.Action != "" {
+		buf = append(buf, `,"action":`...)
+		buf = appendString(buf, e.Action)
+	}
+	if e.Label != "" {
+		buf = append(buf, `,"label":`...)
+		buf = appendString(buf, e.Label)
+	}
+	if e.Value != 0 {
+		buf = append(buf, `,"value":`...)
+		var err error
+		if buf, err = appendFloat(buf, e.Value, 64); err != nil {
+			return buf, err
+		}
+	}
+	if e.Consent != "" {
+		buf = append(buf, `,"consent":`...)
+		buf = appendString(buf, e.Consent)
+	}
+	if e.SampleRate != 0 {
+		buf = append(buf, `,"sample_rate":`...)
+		var err error
+		if buf, err = appendFloat(buf, e.SampleRate, 64); err != nil {
+			return buf, err
+		}
+	}
+	return append(buf, '}'), nil
+}
+
+// keyScratch 复用 map 键排序的切片
+var keyScratch = sync.Pool{New: func() interface{} { s := make([]string, 0, 16); return &s }}
+
+// appendMap 按键排序编码 map，与 encoding/json 一致
+func appendMap(buf []byte, m map[string]interface{}) ([]byte, error) {
+	if m == nil {
+		return append(buf, "null"...), nil
+	}
+	keysPtr := keyScratch.Get().(*[]string)
+	keys := (*keysPtr)[:0]
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var err error
+	buf = append(buf, '{')
+	for i, k := range keys {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendString(buf, k)
+		buf = append(buf, ':')
+		if buf, err = appendValue(buf, m[k]); err != nil {
+			break
+		}
+	}
+
+	clear(keys)
+	*keysPtr = keys[:0]
+	keyScratch.Put(keysPtr)
+	if err != nil {
+		return buf, err
+	}
+	return append(buf, '}'), nil
+}
+
+// appendValue 编码常见类型，其余类型回退到 encoding/json
+func appendValue(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, "null"...), nil
+	case string:
+		return appendString(buf, v), nil
+	case bool:
+		return strconv.AppendBool(buf, v), nil
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10), nil
+	case int8:
+		return strconv.AppendInt(buf, int64(v), 10), nil
+	case int16:
+		return strconv.AppendInt(buf, int64(v), 10), nil
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10), nil
+	case int64:
+		return strconv.AppendInt(buf, v, 10), nil
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10), nil
+	case uint8:
+		return strconv.AppendUint(buf, uint64(v), 10), nil
+	case uint16:
+		return strconv.AppendUint(buf, uint64(v), 10), nil
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10), nil
+	case uint64:
+		return strconv.AppendUint(buf, v, 10), nil
+	case float32:
+		return appendFloat(buf, float64(v), 32)
+	case float64:
+		return appendFloat(buf, v, 64)
+	case map[string]interface{}:
+		return appendMap(buf, v)
+	case []interface{}:
+		if v == nil {
+			return append(buf, "null"...), nil
+		}
+		buf = append(buf, '[')
+		for i, item := range v {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			var err error
+			if buf, err = appendValue(buf, item); err != nil {
+				return buf, err
+			}
+		}
+		return append(buf, ']'), nil
+	case []string:
+		if v == nil {
+			return append(buf, "null"...), nil
+		}
+		buf = append(buf, '[')
+		for i, item := range v {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendString(buf, item)
+		}
+		return append(buf, ']'), nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return buf, err
+		}
+		return append(buf, data...), nil
+	}
+}
+
+// appendFloat 按 encoding/json 的规则编码浮点数
+func appendFloat(buf []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return buf, &json.UnsupportedValueError{Str: strconv.FormatFloat(f, 'g', -1, bits)}
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, bits)
+	if format == 'e' {
+		// 与 encoding/json 一致：e-09 写作 e-9
+		if n := len(buf); n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf, nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendString 按 encoding/json 的规则（包括 HTML 转义）编码字符串
+func appendString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				buf = append(buf, '\\', b)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			case '\b':
+				buf = append(buf, '\\', 'b')
+			case '\f':
+				buf = append(buf, '\\', 'f')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
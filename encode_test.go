@@ -0,0 +1,191 @@
+package analytics
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestAppendBatchMatchesEncodingJSON 测试流式编码与 encoding/json 的输出逐字节一致
+func TestAppendBatchMatchesEncodingJSON(t *testing.T) {
+	events := []*Event{
+		{Name: "plain", Timestamp: 1700000000},
+		{
+			Name:      "escaped \"<html>\" & \\ \n\r\t\b\f \x01    \xff 中文",
+			Timestamp: -1,
+			Properties: map[string]interface{}{
+				"string":  "value",
+				"bool":    true,
+				"int":     42,
+				"int64":   int64(-7),
+				"uint8":   uint8(255),
+				"float":   3.14,
+				"small":   1e-9,
+				"large":   1e21,
+				"float32": float32(0.1),
+				"nil":     nil,
+				"nested":  map[string]interface{}{"b": 1, "a": []interface{}{"x", 2.5, nil}},
+				"strings": []string{"a", "b"},
+				"time":    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				"z<key>":  "last",
+			},
+		},
+		{Name: "ga", Timestamp: 1, Category: "video", Action: "play", Label: "intro", Value: 1.5},
+		{Name: "sampled", Timestamp: 2, Consent: ConsentCrash, SampleRate: 0.25},
+		{Name: "empty props", Properties: map[string]interface{}{}},
+	}
+	payload := batchPayload{
+		Product:   "Test<App>",
+		DeviceID:  "device",
+		UserID:    "",
+		SessionID: "session",
+		Events:    events,
+	}
+
+	want, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	got, _, err := appendBatch(nil, &payload)
+	if err != nil {
+		t.Fatalf("appendBatch: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("appendBatch output differs from encoding/json\n got: %s\nwant: %s", got, want)
+	}
+}
+
+// TestAppendBatchUnsupportedValue 测试无法编码的事件被跳过，同批次的其他事件正常编码
+func TestAppendBatchUnsupportedValue(t *testing.T) {
+	for _, v := range []interface{}{math.NaN(), math.Inf(1), make(chan int)} {
+		bad := &Event{Name: "bad", Properties: map[string]interface{}{"v": v}}
+		payload := batchPayload{Events: []*Event{{Name: "a"}, bad, {Name: "b"}}}
+		got, dropped, err := appendBatch(nil, &payload)
+		if err == nil {
+			t.Errorf("appendBatch(%T) error = nil, want error", v)
+		}
+		if len(dropped) != 1 || dropped[0] != bad {
+			t.Errorf("appendBatch(%T) dropped = %v, want the bad event", v, dropped)
+		}
+		var decoded batchPayload
+		if err := json.Unmarshal(got, &decoded); err != nil {
+			t.Fatalf("appendBatch(%T) produced invalid JSON %s: %v", v, got, err)
+		}
+		if len(decoded.Events) != 2 || len(payload.Events) != 2 {
+			t.Errorf("appendBatch(%T) kept %d/%d events, want 2", v, len(decoded.Events), len(payload.Events))
+		}
+	}
+}
+
+// TestPayloadBufferRefs 测试缓冲区在所有请求体关闭后才归还
+func TestPayloadBufferRefs(t *testing.T) {
+	buf := getPayloadBuffer()
+	buf.data = append(buf.data, "body"...)
+
+	r := buf.reader()
+	buf.release()
+	if got := buf.refs.Load(); got != 1 {
+		t.Fatalf("refs = %d after owner release, want 1 held by reader", got)
+	}
+	data, _ := io.ReadAll(r)
+	if string(data) != "body" {
+		t.Errorf("read %q, want body", data)
+	}
+	r.Close()
+	r.Close()
+	if got := buf.refs.Load(); got != 0 {
+		t.Errorf("refs = %d after close, want 0", got)
+	}
+}
+
+// benchmarkEvents 返回基准测试使用的批次
+func benchmarkEvents(n int) []*Event {
+	events := make([]*Event, n)
+	for i := range events {
+		events[i] = &Event{
+			Name:      "api_request",
+			Timestamp: 1700000000,
+			Properties: map[string]interface{}{
+				"method":      "GET",
+				"path":        "/api/users/:id",
+				"status":      200,
+				"duration_ms": 12.5,
+				"cached":      false,
+			},
+		}
+	}
+	return events
+}
+
+// BenchmarkEncodeBatch 流式编码到池化缓冲区
+func BenchmarkEncodeBatch(b *testing.B) {
+	payload := batchPayload{Product: "BenchApp", DeviceID: "device", SessionID: "session", Events: benchmarkEvents(20)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := getPayloadBuffer()
+		buf.data, _, _ = appendBatch(buf.data, &payload)
+		buf.release()
+	}
+}
+
+// BenchmarkEncodeBatchReflect 原先基于 map 与反射的编码，作为对照
+func BenchmarkEncodeBatchReflect(b *testing.B) {
+	events := benchmarkEvents(20)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		json.Marshal(map[string]interface{}{
+			"product":    "BenchApp",
+			"device_id":  "device",
+			"user_id":    "",
+			"session_id": "session",
+			"events":     events,
+		})
+	}
+}
+
+// BenchmarkTrack 测量 Track 的分配次数（包括分摊的组批与发送）
+func BenchmarkTrack(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "BenchApp",
+		WithBatchSize(100),
+		WithBufferSize(b.N+1),
+	)
+	props := map[string]interface{}{"method": "GET", "status": 200}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client.Track("api_request", props)
+	}
+	client.Close()
+}
+
+// TestEventSizeMatchesJSON 测试事件大小与 encoding/json 的编码长度一致
+func TestEventSizeMatchesJSON(t *testing.T) {
+	for _, e := range benchmarkEvents(5) {
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := eventSize(e), len(data)+1; got != want {
+			t.Errorf("eventSize() = %d, want %d", got, want)
+		}
+	}
+}
+
+// BenchmarkEventSize 按字节数组批时计算事件大小
+func BenchmarkEventSize(b *testing.B) {
+	event := benchmarkEvents(1)[0]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		eventSize(event)
+	}
+}
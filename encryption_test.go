@@ -7,8 +7,8 @@ import (
 	analytics "github.com/difyz9/go-analysis-client"
 )
 
-// ExampleWithEncryptionOption 演示使用 WithEncryption 选项启用加密
-func ExampleWithEncryptionOption() {
+// ExampleWithEncryption 演示使用 WithEncryption 选项启用加密
+func ExampleWithEncryption() {
 	// 创建支持加密的客户端
 	client := analytics.NewClient(
 		"http://localhost:8080",
@@ -0,0 +1,277 @@
+package analytics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 多端点故障转移与健康检查
+// =============================================================================
+
+// 健康检查默认参数
+const (
+	DefaultHealthCheckPath     = "/health"
+	DefaultHealthCheckInterval = 30 * time.Second
+)
+
+// EndpointStats 单个端点的统计信息
+type EndpointStats struct {
+	URL         string
+	Active      bool      // 当前优先使用的端点
+	Healthy     bool      // 最近一次请求或健康检查是否成功
+	Batches     int64     // 成功发送的事件批次数
+	Events      int64     // 成功发送的事件数
+	Failures    int64     // 失败的请求数
+	LastError   string    // 最近一次失败的错误
+	LastSuccess time.Time // 最近一次成功的时间
+}
+
+// endpoint 单个服务端地址及其统计
+type endpoint struct {
+	url   string
+	stats EndpointStats
+}
+
+// endpointSet 按优先级排列的端点，active 为当前优先使用的端点
+type endpointSet struct {
+	healthPath     string
+	healthInterval time.Duration
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+	active    int
+}
+
+// WithEndpoints 设置主端点与按顺序使用的备用端点，覆盖 NewClient 的 serverURL
+//
+// 请求遇到可重试的错误（网络错误或 5xx）时依次尝试下一个端点，成功后继续使用该端点；
+// 定期健康检查发现更高优先级的端点恢复后切回。配置了备用端点时默认启用健康检查。
+func WithEndpoints(primary string, fallbacks ...string) ClientOption {
+	return func(c *Client) {
+		c.serverURL = primary
+		c.fallbacks = fallbacks
+	}
+}
+
+// WithHealthCheck 设置健康检查路径与间隔，默认 GET /health，每 30 秒一次
+//
+// 服务端返回 5xx 以外的任何响应都视为可达。
+func WithHealthCheck(path string, interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.healthPath = path
+		c.healthInterval = interval
+	}
+}
+
+// initEndpoints 根据 serverURL、备用端点与健康检查选项创建端点集合
+func (c *Client) initEndpoints() {
+	urls := append([]string{c.serverURL}, c.fallbacks...)
+	path, interval := c.healthPath, c.healthInterval
+	if len(urls) > 1 || path != "" {
+		if path == "" {
+			path = DefaultHealthCheckPath
+		}
+		if interval <= 0 {
+			interval = DefaultHealthCheckInterval
+		}
+	} else {
+		interval = 0
+	}
+	c.endpoints = newEndpointSet(urls, path, interval)
+}
+
+// newEndpointSet 创建端点集合
+func newEndpointSet(urls []string, healthPath string, healthInterval time.Duration) *endpointSet {
+	s := &endpointSet{healthPath: healthPath, healthInterval: healthInterval}
+	for _, u := range urls {
+		u = strings.TrimRight(u, "/")
+		s.endpoints = append(s.endpoints, &endpoint{url: u, stats: EndpointStats{URL: u, Healthy: true}})
+	}
+	return s
+}
+
+// order 返回本次请求的尝试顺序：当前端点优先，其余按配置顺序
+func (s *endpointSet) order() []*endpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order := make([]*endpoint, 0, len(s.endpoints))
+	order = append(order, s.endpoints[s.active])
+	for i, ep := range s.endpoints {
+		if i != s.active {
+			order = append(order, ep)
+		}
+	}
+	return order
+}
+
+// activeURL 返回当前端点的地址
+func (s *endpointSet) activeURL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.endpoints[s.active].url
+}
+
+// success 记录成功的请求，并将该端点设为当前端点
+func (s *endpointSet) success(ep *endpoint, events int) (switched bool, from string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ep.stats.Healthy = true
+	ep.stats.LastSuccess = time.Now()
+	if events > 0 {
+		ep.stats.Batches++
+		ep.stats.Events += int64(events)
+	}
+	return s.activate(ep)
+}
+
+// failure 记录失败的请求
+func (s *endpointSet) failure(ep *endpoint, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ep.stats.Healthy = false
+	ep.stats.Failures++
+	ep.stats.LastError = err.Error()
+}
+
+// activate 切换当前端点（调用方需持有锁）
+func (s *endpointSet) activate(ep *endpoint) (bool, string) {
+	from := s.endpoints[s.active]
+	if from == ep {
+		return false, ""
+	}
+	for i, e := range s.endpoints {
+		if e == ep {
+			s.active = i
+		}
+	}
+	return true, from.url
+}
+
+// EndpointStats 返回各端点的统计信息，按配置顺序排列
+func (c *Client) EndpointStats() []EndpointStats {
+	c.endpoints.mu.Lock()
+	defer c.endpoints.mu.Unlock()
+	stats := make([]EndpointStats, len(c.endpoints.endpoints))
+	for i, ep := range c.endpoints.endpoints {
+		stats[i] = ep.stats
+		stats[i].Active = i == c.endpoints.active
+	}
+	return stats
+}
+
+// ActiveEndpoint 返回当前优先使用的端点地址
+func (c *Client) ActiveEndpoint() string {
+	return c.endpoints.activeURL()
+}
+
+// post 按故障转移顺序向各端点发送请求
+//
+// 仅在可重试的错误时尝试下一个端点。buf 非空时 body 即 buf.data，请求体引用池化的缓冲区。
+// batchSize 大于 0 表示事件批次，每次尝试都会通知 SendObserver 并计入端点统计。
+func (c *Client) post(ctx context.Context, path, contentType string, body []byte, buf *payloadBuffer, batchSize int) (*http.Response, error) {
+	var lastErr error
+	for _, ep := range c.endpoints.order() {
+		url := ep.url + path
+
+		finish := func(int, error) {}
+		reqCtx := ctx
+		if batchSize > 0 {
+			reqCtx, finish = c.startSend(ctx, SendInfo{URL: url, BatchSize: batchSize, Bytes: len(body)})
+		}
+		resp, err := c.postJSON(reqCtx, url, contentType, body, buf)
+		if err == nil {
+			finish(resp.StatusCode, nil)
+			if switched, from := c.endpoints.success(ep, batchSize); switched {
+				c.logEndpointSwitch(from, ep.url)
+			}
+			return resp, nil
+		}
+
+		finish(statusCodeOf(err), err)
+		c.endpoints.failure(ep, err)
+		lastErr = err
+		if !isRetryableError(err) || ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// runHealthChecks 定期检查各端点，更高优先级的端点恢复后切回
+func (c *Client) runHealthChecks() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.endpoints.healthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.quit:
+			return
+		case <-ticker.C:
+			c.checkEndpoints(c.ctx)
+		}
+	}
+}
+
+// checkEndpoints 检查所有端点的健康状态，并切换到优先级最高的健康端点
+func (c *Client) checkEndpoints(ctx context.Context) {
+	s := c.endpoints
+	s.mu.Lock()
+	endpoints := append([]*endpoint(nil), s.endpoints...)
+	s.mu.Unlock()
+
+	healthy := make([]bool, len(endpoints))
+	for i, ep := range endpoints {
+		healthy[i] = c.checkEndpoint(ctx, ep.url+s.healthPath)
+	}
+
+	s.mu.Lock()
+	var switched bool
+	var from, to string
+	for i, ep := range endpoints {
+		ep.stats.Healthy = healthy[i]
+	}
+	for i, ep := range endpoints {
+		if healthy[i] {
+			switched, from = s.activate(ep)
+			to = ep.url
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if switched {
+		c.logEndpointSwitch(from, to)
+	}
+}
+
+// checkEndpoint 请求健康检查地址，5xx 以外的响应视为可达
+func (c *Client) checkEndpoint(ctx context.Context, url string) bool {
+	if c.configErr != nil {
+		return false
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < 500
+}
+
+// logEndpointSwitch 记录端点切换
+func (c *Client) logEndpointSwitch(from, to string) {
+	if c.debug && c.logger != nil {
+		c.logger.Printf("[Analytics] Switched endpoint %s -> %s", from, to)
+	}
+	c.logAttrs(slog.LevelWarn, "analytics: switched endpoint", slog.String("from", from), slog.String("to", to))
+}
@@ -0,0 +1,90 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+)
+
+// TestEndpointFailover 测试可重试错误时按顺序切换端点并保持在备用端点
+func TestEndpointFailover(t *testing.T) {
+	primary := newEventCollector(t)
+	fallback := newEventCollector(t)
+	primary.status.Store(503)
+
+	client := NewClient("unused", "TestApp",
+		WithEndpoints(primary.URL, fallback.URL),
+		WithHealthCheck("/health", time.Hour),
+		WithBatchSize(1),
+		WithFlushInterval(time.Hour),
+	)
+	defer client.Close()
+
+	client.Track("first", nil)
+	waitFor(t, func() bool { return len(fallback.Events()) == 1 })
+	if client.ActiveEndpoint() != fallback.URL {
+		t.Fatalf("active endpoint = %s, want fallback", client.ActiveEndpoint())
+	}
+
+	// 主端点恢复后，在健康检查之前仍使用备用端点
+	primary.status.Store(0)
+	primaryRequests := primary.requests.Load()
+	client.Track("second", nil)
+	waitFor(t, func() bool { return len(fallback.Events()) == 2 })
+	if primary.requests.Load() != primaryRequests {
+		t.Error("primary was retried before a health check")
+	}
+
+	stats := client.EndpointStats()
+	if len(stats) != 2 {
+		t.Fatalf("got %d endpoint stats, want 2", len(stats))
+	}
+	if stats[0].Failures != 1 || stats[0].Active {
+		t.Errorf("primary stats = %+v, want 1 failure and inactive", stats[0])
+	}
+	if stats[1].Batches != 2 || stats[1].Events != 2 || !stats[1].Active {
+		t.Errorf("fallback stats = %+v, want 2 batches and active", stats[1])
+	}
+
+	// 健康检查发现主端点恢复后切回
+	client.checkEndpoints(client.ctx)
+	if client.ActiveEndpoint() != primary.URL {
+		t.Fatalf("active endpoint = %s after health check, want primary", client.ActiveEndpoint())
+	}
+	client.Track("third", nil)
+	waitFor(t, func() bool { return len(primary.Events()) == 1 })
+}
+
+// TestEndpointNoFailoverOnClientError 测试 4xx 不触发故障转移
+func TestEndpointNoFailoverOnClientError(t *testing.T) {
+	primary := newEventCollector(t)
+	fallback := newEventCollector(t)
+	primary.status.Store(400)
+
+	client := NewClient(primary.URL, "TestApp", WithEndpoints(primary.URL, fallback.URL))
+	defer client.Close()
+
+	if err := client.sendEvents(client.ctx, []*Event{{Name: "bad"}}); err == nil {
+		t.Fatal("sendEvents succeeded on 400")
+	}
+	if fallback.requests.Load() != 0 {
+		t.Error("fallback was used for a non-retryable error")
+	}
+}
+
+// TestHealthCheckLoop 测试后台健康检查
+func TestHealthCheckLoop(t *testing.T) {
+	primary := newEventCollector(t)
+	fallback := newEventCollector(t)
+
+	client := NewClient(primary.URL, "TestApp",
+		WithEndpoints(primary.URL, fallback.URL),
+		WithHealthCheck("/health", 10*time.Millisecond),
+	)
+	defer client.Close()
+
+	client.endpoints.mu.Lock()
+	client.endpoints.active = 1
+	client.endpoints.mu.Unlock()
+
+	waitFor(t, func() bool { return client.ActiveEndpoint() == primary.URL })
+}
@@ -35,6 +35,9 @@ var (
 	// ErrInvalidKey 密钥无效
 	ErrInvalidKey = errors.New("invalid encryption key")
 	
+	// ErrUnknownKeyID 密钥 ID 不存在
+	ErrUnknownKeyID = errors.New("unknown encryption key ID")
+	
 	// ErrMarshalFailed JSON 序列化失败
 	ErrMarshalFailed = errors.New("failed to marshal data")
 	
@@ -49,6 +52,42 @@ var (
 	
 	// ErrBufferFull 事件缓冲区已满
 	ErrBufferFull = errors.New("event buffer is full")
+	
+	// ErrCircuitOpen 熔断器打开，发送被短路
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	
+	// ErrRateLimited 事件被限流
+	ErrRateLimited = errors.New("event rate limited")
+	
+	// ErrSchemaViolation 事件违反跟踪计划
+	ErrSchemaViolation = errors.New("event violates tracking plan")
+	
+	// ErrMissingSignature 请求缺少签名
+	ErrMissingSignature = errors.New("missing request signature")
+	
+	// ErrInvalidSignature 请求签名无效
+	ErrInvalidSignature = errors.New("invalid request signature")
+	
+	// ErrSignatureExpired 请求签名已过期
+	ErrSignatureExpired = errors.New("request signature expired")
+	
+	// ErrReplayedRequest 请求被重放
+	ErrReplayedRequest = errors.New("replayed request")
+
+	// ErrRequestTooLarge 请求体超过允许的大小
+	ErrRequestTooLarge = errors.New("request body too large")
+	
+	// ErrCertificatePinMismatch 服务端证书与固定的公钥不匹配
+	ErrCertificatePinMismatch = errors.New("certificate pin mismatch")
+	
+	// ErrSigningKeyRequired 请求需要配置 WithSigningKey
+	ErrSigningKeyRequired = errors.New("signing key required")
+
+	// ErrIdentityFileRequired 请求需要配置 WithIdentityFile
+	ErrIdentityFileRequired = errors.New("identity file required")
+
+	// ErrUndefinedEvent 事件未在事件目录中定义，或属性类型与定义不一致
+	ErrUndefinedEvent = errors.New("event not defined in catalog")
 )
 
 // =============================================================================
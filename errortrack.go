@@ -0,0 +1,306 @@
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 错误追踪
+// =============================================================================
+
+// ErrorEventName 错误事件名称
+const ErrorEventName = "error"
+
+// ErrorSuppressedEventName 被清理的指纹窗口中仍有被抑制的错误时，上报的汇总事件名称
+const ErrorSuppressedEventName = "error_suppressed"
+
+// 错误链最大展开深度，防止循环引用的错误无限展开
+const maxErrorChainDepth = 32
+
+// volatileTokens 匹配消息中的易变片段（数字、十六进制 ID、UUID），计算指纹时忽略
+var volatileTokens = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b|\b0x[0-9a-f]+\b|\d+`)
+
+// WithErrorStack 设置 TrackError 是否附带调用堆栈，默认不附带
+func WithErrorStack(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.errorStack = enabled
+	}
+}
+
+// WithErrorRateLimit 设置相同指纹错误的限流：每个 interval 内最多上报 limit 次
+//
+// 被抑制的次数会以 suppressed_count 属性附加在下一个窗口的首个同指纹事件上；
+// 该指纹不再出现、窗口被清理时，以 error_suppressed 事件（fingerprint、suppressed_count）单独上报。
+// 默认每分钟 10 次；limit <= 0 表示不限流。
+func WithErrorRateLimit(limit int, interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.errorLimiter = newFingerprintLimiter(limit, interval)
+	}
+}
+
+// ErrorFrame 错误链中的一层
+type ErrorFrame struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// TrackError 上报错误事件（异步）
+//
+// 展开错误链（包括 errors.Join 与本包的 ClientError/NetworkError），记录各层类型与消息，
+// 并计算稳定指纹用于聚合。相同指纹的错误按 WithErrorRateLimit 限流，
+// 避免错误循环占满事件缓冲区。
+//
+//	if err := doWork(); err != nil {
+//	    client.TrackError(err, map[string]interface{}{"job": "sync"})
+//	}
+func (c *Client) TrackError(err error, properties map[string]interface{}) {
+	if err == nil || !c.consent.allows(ConsentCrash) {
+		return
+	}
+	c.trackError(callerFunction(2), err, properties)
+}
+
+// TrackErrorAt 与 TrackError 相同，但使用指定的调用位置计算指纹
+//
+// 供封装 TrackError 的集成包使用，否则所有错误的调用位置都是封装函数本身，
+// 不相关的错误会得到相同的指纹并被一起限流。caller 通常为函数名，也可以是路由等标识。
+func (c *Client) TrackErrorAt(caller string, err error, properties map[string]interface{}) {
+	if err == nil || !c.consent.allows(ConsentCrash) {
+		return
+	}
+	c.trackError(caller, err, properties)
+}
+
+// trackError 按调用位置计算指纹并上报错误事件
+func (c *Client) trackError(caller string, err error, properties map[string]interface{}) {
+	fingerprint := ErrorFingerprint(err, caller)
+
+	allowed, suppressed, evicted := c.errorLimiter.allow(fingerprint, time.Now())
+	for fp, n := range evicted {
+		c.TrackWithConsent(ConsentCrash, ErrorSuppressedEventName, map[string]interface{}{
+			"fingerprint":      fp,
+			"suppressed_count": n,
+		})
+	}
+	if !allowed {
+		return
+	}
+
+	props := make(map[string]interface{}, len(properties)+8)
+	for k, v := range properties {
+		props[k] = v
+	}
+
+	chain := ErrorChain(err)
+	props["error_message"] = err.Error()
+	props["error_type"] = chain[0].Type
+	props["error_chain"] = chain
+	props["fingerprint"] = fingerprint
+	if caller != "" {
+		props["caller"] = caller
+	}
+	if suppressed > 0 {
+		props["suppressed_count"] = suppressed
+	}
+
+	var clientErr *ClientError
+	if errors.As(err, &clientErr) {
+		props["op"] = clientErr.Op
+		for k, v := range clientErr.Context {
+			props["context."+k] = v
+		}
+	}
+	var netErr *NetworkError
+	if errors.As(err, &netErr) {
+		props["http_method"] = netErr.Op
+		props["url"] = netErr.URL
+		props["status_code"] = netErr.StatusCode
+		props["retryable"] = netErr.Retryable
+	}
+
+	if c.errorStack {
+		props["stack"] = callerStack(3)
+	}
+
+	c.TrackWithConsent(ConsentCrash, ErrorEventName, props)
+}
+
+// ErrorChain 展开错误链，返回从外到内的各层类型与消息
+//
+// 对 errors.Join 等多重包装的错误按深度优先顺序展开。
+func ErrorChain(err error) []ErrorFrame {
+	var frames []ErrorFrame
+	var walk func(e error, depth int)
+	walk = func(e error, depth int) {
+		if e == nil || depth >= maxErrorChainDepth {
+			return
+		}
+		frames = append(frames, ErrorFrame{Type: fmt.Sprintf("%T", e), Message: e.Error()})
+		switch u := e.(type) {
+		case interface{ Unwrap() []error }:
+			for _, inner := range u.Unwrap() {
+				walk(inner, depth+1)
+			}
+		case interface{ Unwrap() error }:
+			walk(u.Unwrap(), depth+1)
+		}
+	}
+	walk(err, 0)
+	return frames
+}
+
+// ErrorFingerprint 计算错误指纹
+//
+// 指纹由错误链的类型序列、最内层错误消息（去除数字与 ID 等易变片段）、
+// ClientError/NetworkError 的操作与状态码以及调用位置（函数名）组成，
+// 相同根因的错误得到相同指纹。
+func ErrorFingerprint(err error, caller string) string {
+	chain := ErrorChain(err)
+	if len(chain) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, f := range chain {
+		b.WriteString(f.Type)
+		b.WriteByte('|')
+	}
+	b.WriteString(volatileTokens.ReplaceAllString(chain[len(chain)-1].Message, "?"))
+
+	var clientErr *ClientError
+	if errors.As(err, &clientErr) {
+		b.WriteString("|op=" + clientErr.Op)
+	}
+	var netErr *NetworkError
+	if errors.As(err, &netErr) {
+		fmt.Fprintf(&b, "|%s|%d", netErr.Op, netErr.StatusCode)
+	}
+	b.WriteString("|" + caller)
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// callerFunction 返回调用者的函数名
+func callerFunction(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return ""
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
+
+// callerStack 返回从调用者开始的调用堆栈
+func callerStack(skip int) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// =============================================================================
+// fingerprintLimiter - 按指纹限流
+// =============================================================================
+
+// fingerprintLimiter 固定窗口限流器，按指纹统计
+type fingerprintLimiter struct {
+	limit    int
+	interval time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*fingerprintWindow
+}
+
+type fingerprintWindow struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// 限流器记录的最大指纹数，达到时清理过期窗口，仍然达到则淘汰最早的窗口
+const maxTrackedFingerprints = 1024
+
+func newFingerprintLimiter(limit int, interval time.Duration) *fingerprintLimiter {
+	return &fingerprintLimiter{
+		limit:    limit,
+		interval: interval,
+		windows:  make(map[string]*fingerprintWindow),
+	}
+}
+
+// allow 判断是否允许上报，返回上一窗口中被抑制的次数（仅在新窗口首次放行时返回），
+// 以及被清理的其他指纹中尚未上报的抑制次数
+func (l *fingerprintLimiter) allow(fingerprint string, now time.Time) (bool, int, map[string]int) {
+	if l == nil || l.limit <= 0 {
+		return true, 0, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.windows[fingerprint]
+	if !ok || now.Sub(w.start) >= l.interval {
+		suppressed := 0
+		if ok {
+			suppressed = w.suppressed
+		}
+		var evicted map[string]int
+		if !ok && len(l.windows) >= maxTrackedFingerprints {
+			evicted = l.sweep(now)
+		}
+		l.windows[fingerprint] = &fingerprintWindow{start: now, count: 1}
+		return true, suppressed, evicted
+	}
+
+	if w.count >= l.limit {
+		w.suppressed++
+		return false, 0, nil
+	}
+	w.count++
+	return true, 0, nil
+}
+
+// sweep 清理已过期的窗口，没有过期窗口时淘汰最早的窗口，
+// 返回被清理的窗口中被抑制的次数
+func (l *fingerprintLimiter) sweep(now time.Time) map[string]int {
+	evicted := make(map[string]int)
+	remove := func(fp string, w *fingerprintWindow) {
+		if w.suppressed > 0 {
+			evicted[fp] = w.suppressed
+		}
+		delete(l.windows, fp)
+	}
+
+	var oldest string
+	for fp, w := range l.windows {
+		if now.Sub(w.start) >= l.interval {
+			remove(fp, w)
+		} else if oldest == "" || w.start.Before(l.windows[oldest].start) {
+			oldest = fp
+		}
+	}
+	if len(l.windows) >= maxTrackedFingerprints {
+		remove(oldest, l.windows[oldest])
+	}
+	return evicted
+}
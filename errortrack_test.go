@@ -0,0 +1,161 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestErrorChain 测试错误链展开
+func TestErrorChain(t *testing.T) {
+	netErr := newNetworkError("POST", "http://example.com", 503, ErrServerResponse, true)
+	err := fmt.Errorf("flush: %w", errors.Join(newClientError("sendEvents", netErr), ErrBufferFull))
+
+	chain := ErrorChain(err)
+	wantTypes := []string{
+		"*fmt.wrapError",
+		"*errors.joinError",
+		"*analytics.ClientError",
+		"*analytics.NetworkError",
+		"*errors.errorString",
+		"*errors.errorString",
+	}
+	if len(chain) != len(wantTypes) {
+		t.Fatalf("ErrorChain() len = %d, want %d: %+v", len(chain), len(wantTypes), chain)
+	}
+	for i, want := range wantTypes {
+		if chain[i].Type != want {
+			t.Errorf("chain[%d].Type = %s, want %s", i, chain[i].Type, want)
+		}
+	}
+	if chain[len(chain)-1].Message != ErrBufferFull.Error() {
+		t.Errorf("innermost message = %q", chain[len(chain)-1].Message)
+	}
+}
+
+// TestErrorFingerprint 测试指纹忽略易变片段但区分不同错误
+func TestErrorFingerprint(t *testing.T) {
+	a := ErrorFingerprint(fmt.Errorf("load: %w", errors.New("user 42 not found")), "pkg.Load")
+	b := ErrorFingerprint(fmt.Errorf("load: %w", errors.New("user 1337 not found")), "pkg.Load")
+	c := ErrorFingerprint(fmt.Errorf("load: %w", errors.New("user 42 not found")), "pkg.Save")
+	d := ErrorFingerprint(newNetworkError("POST", "u", 500, ErrServerResponse, true), "pkg.Load")
+	e := ErrorFingerprint(newNetworkError("POST", "u", 404, ErrServerResponse, false), "pkg.Load")
+
+	if a != b {
+		t.Errorf("fingerprints differ for volatile values: %s != %s", a, b)
+	}
+	if a == c {
+		t.Error("fingerprints equal for different callers")
+	}
+	if d == e {
+		t.Error("fingerprints equal for different status codes")
+	}
+}
+
+// TestTrackErrorRateLimit 测试相同指纹的错误被限流
+func TestTrackErrorRateLimit(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp",
+		WithErrorRateLimit(3, time.Hour),
+		WithErrorStack(true),
+	)
+
+	err := newClientErrorWithContext("Sync", ErrNetworkTimeout, map[string]interface{}{"attempt": 1})
+	for i := 0; i < 100; i++ {
+		client.TrackError(err, map[string]interface{}{"loop": true})
+	}
+	client.TrackError(ErrBufferFull, nil)
+	client.Close()
+
+	events := server.Events()
+	if len(events) != 4 {
+		t.Fatalf("received %d events, want 4", len(events))
+	}
+	props := events[0].Properties
+	if events[0].Name != ErrorEventName || props["op"] != "Sync" || props["context.attempt"] != float64(1) ||
+		props["loop"] != true || props["error_type"] != "*analytics.ClientError" {
+		t.Errorf("unexpected error event: %v", props)
+	}
+	if props["stack"] == nil || props["fingerprint"] == "" {
+		t.Error("error event missing stack or fingerprint")
+	}
+	if events[3].Properties["fingerprint"] == props["fingerprint"] {
+		t.Error("different errors share a fingerprint")
+	}
+}
+
+// TestFingerprintLimiterSuppressedCount 测试新窗口首个事件携带被抑制的次数
+func TestFingerprintLimiterSuppressedCount(t *testing.T) {
+	l := newFingerprintLimiter(1, time.Minute)
+	now := time.Now()
+
+	if ok, _, _ := l.allow("fp", now); !ok {
+		t.Fatal("first call should be allowed")
+	}
+	for i := 0; i < 5; i++ {
+		if ok, _, _ := l.allow("fp", now); ok {
+			t.Fatal("calls over the limit should be suppressed")
+		}
+	}
+	ok, suppressed, _ := l.allow("fp", now.Add(time.Minute))
+	if !ok || suppressed != 5 {
+		t.Errorf("allow() in new window = %v, %d; want true, 5", ok, suppressed)
+	}
+}
+
+// TestFingerprintLimiterSweep 测试清理窗口时上报被抑制的次数，且记录的指纹数有上限
+func TestFingerprintLimiterSweep(t *testing.T) {
+	l := newFingerprintLimiter(1, time.Minute)
+	now := time.Now()
+
+	l.allow("expired", now)
+	l.allow("expired", now)
+	l.allow("expired", now)
+	for i := 1; i < maxTrackedFingerprints; i++ {
+		l.allow(fmt.Sprintf("fp-%d", i), now.Add(time.Minute))
+	}
+
+	// 过期窗口被清理时返回其被抑制的次数
+	_, _, evicted := l.allow("new", now.Add(time.Minute))
+	if evicted["expired"] != 2 || len(evicted) != 1 {
+		t.Errorf("evicted = %v, want expired: 2", evicted)
+	}
+
+	// 没有过期窗口时淘汰最早的窗口，而不是无限增长
+	l.allow("fp-1", now.Add(time.Minute))
+	for i := 0; i < 10; i++ {
+		l.allow(fmt.Sprintf("more-%d", i), now.Add(time.Minute+time.Duration(i+1)*time.Second))
+	}
+	if n := len(l.windows); n > maxTrackedFingerprints {
+		t.Errorf("tracked %d fingerprints, want at most %d", n, maxTrackedFingerprints)
+	}
+}
+
+// TestTrackErrorReportsEvictedSuppressed 测试被清理的窗口中被抑制的次数以汇总事件上报，
+// 严格的跟踪计划也不会拒绝该内置事件
+func TestTrackErrorReportsEvictedSuppressed(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp", WithFlushInterval(time.Hour), WithBufferSize(4096),
+		WithTrackingPlan(mustPlan(t), SchemaReject))
+
+	now := time.Now()
+	client.errorLimiter = newFingerprintLimiter(1, time.Minute)
+	client.errorLimiter.allow("looping", now.Add(-time.Hour))
+	client.errorLimiter.allow("looping", now.Add(-time.Hour))
+	for i := 1; i < maxTrackedFingerprints; i++ {
+		client.errorLimiter.allow(fmt.Sprintf("fp-%d", i), now)
+	}
+	client.TrackError(ErrBufferFull, nil)
+	client.Close()
+
+	var found bool
+	for _, e := range server.Events() {
+		if e.Name == ErrorSuppressedEventName {
+			found = e.Properties["fingerprint"] == "looping" && e.Properties["suppressed_count"] == float64(1)
+		}
+	}
+	if !found {
+		t.Error("suppressed count of the evicted fingerprint was not reported")
+	}
+}
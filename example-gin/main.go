@@ -3,10 +3,11 @@ package main
 import (
 	"log"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	analytics "github.com/difyz9/go-analysis-client"
+	"github.com/difyz9/go-analysis-client/analyticshttp"
+	analyticsgin "github.com/difyz9/go-analysis-client/gin"
 )
 
 var analyticsClient *analytics.Client
@@ -19,6 +20,10 @@ func main() {
 		analytics.WithDebug(true),
 		analytics.WithLogger(log.Default()),
 		analytics.WithBatchSize(50),
+		// 高频的请求事件按用户采样 10%：服务端所有请求共用一个设备ID，
+		// 按设备采样会让请求全部保留或全部丢弃；没有用户的请求逐个采样
+		analytics.WithSamplingRules(analytics.SamplingRule{Pattern: analyticshttp.DefaultEventName, Rate: 0.1}),
+		analytics.WithSamplingKey(analytics.SampleByUser),
 	)
 	defer analyticsClient.Close()
 
@@ -28,8 +33,11 @@ func main() {
 	// 创建 Gin 路由
 	r := gin.Default()
 
-	// 使用分析中间件
-	r.Use(AnalyticsMiddleware(analyticsClient))
+	// 使用分析中间件（路由模板取自 c.FullPath()，错误与 panic 自动上报）
+	r.Use(analyticsgin.Middleware(analyticsClient, &analyticshttp.Options{
+		ExcludePaths: []string{"/healthz"},
+		UserFunc:     func(r *http.Request) string { return r.Header.Get("X-User-ID") },
+	}))
 
 	// 定义路由
 	r.GET("/", func(c *gin.Context) {
@@ -46,8 +54,8 @@ func main() {
 	})
 
 	r.POST("/api/users", func(c *gin.Context) {
-		// 手动跟踪特定事件
-		analyticsClient.Track("user_created", map[string]interface{}{
+		// 通过请求级 Tracker 跟踪特定事件
+		analyticsgin.Tracker(c).Track("user_created", map[string]interface{}{
 			"source": "api",
 			"ip": c.ClientIP(),
 		})
@@ -61,7 +69,7 @@ func main() {
 		productID := c.Param("id")
 		
 		// 跟踪产品查看
-		analyticsClient.Track("product_view", map[string]interface{}{
+		analyticsgin.Tracker(c).Track("product_view", map[string]interface{}{
 			"product_id": productID,
 			"user_agent": c.GetHeader("User-Agent"),
 		})
@@ -77,35 +85,3 @@ func main() {
 		log.Fatal(err)
 	}
 }
-
-// AnalyticsMiddleware 分析中间件
-func AnalyticsMiddleware(client *analytics.Client) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-
-		// 处理请求
-		c.Next()
-
-		// 计算请求时长
-		duration := time.Since(start)
-
-		// 跟踪请求
-		client.Track("http_request", map[string]interface{}{
-			"method":      c.Request.Method,
-			"path":        c.Request.URL.Path,
-			"status":      c.Writer.Status(),
-			"duration_ms": duration.Milliseconds(),
-			"ip":          c.ClientIP(),
-			"user_agent":  c.GetHeader("User-Agent"),
-		})
-
-		// 如果有错误，单独跟踪
-		if len(c.Errors) > 0 {
-			client.Track("http_error", map[string]interface{}{
-				"method": c.Request.Method,
-				"path":   c.Request.URL.Path,
-				"errors": c.Errors.String(),
-			})
-		}
-	}
-}
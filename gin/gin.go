@@ -0,0 +1,77 @@
+// Package analyticsgin 为 Gin 提供分析统计中间件
+//
+//	r := gin.New()
+//	r.Use(gin.Recovery(), analyticsgin.Middleware(client, nil))
+//
+//	r.GET("/users/:id", func(c *gin.Context) {
+//	    analyticsgin.Tracker(c).Track("user_viewed", nil)
+//	})
+//
+// 路由模板取自 c.FullPath()，c.Errors 中的错误与处理器 panic 都会被上报。
+// 本包是独立的 Go module，核心 SDK 不依赖 Gin。
+package analyticsgin
+
+import (
+	"errors"
+	"time"
+
+	analytics "github.com/difyz9/go-analysis-client"
+	"github.com/difyz9/go-analysis-client/analyticshttp"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware 返回记录请求统计的 Gin 中间件
+//
+// 处理器 panic 时会记录请求事件与错误事件后重新 panic，应将其注册在 gin.Recovery 之后。
+func Middleware(client *analytics.Client, opts *analyticshttp.Options) gin.HandlerFunc {
+	rec := analyticshttp.NewRecorder(client, opts)
+
+	return func(c *gin.Context) {
+		if rec.Excluded(c.Request) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		req, tracker := rec.Begin(c.Request)
+		c.Request = req
+
+		defer func() {
+			p := recover()
+
+			var err error
+			if len(c.Errors) > 0 {
+				errs := make([]error, len(c.Errors))
+				for i, e := range c.Errors {
+					errs[i] = e.Err
+				}
+				err = errors.Join(errs...)
+			}
+
+			route := c.FullPath()
+			if route == "" {
+				route = analyticshttp.TemplatePath(c.Request.URL.Path)
+			}
+
+			rec.Finish(tracker, analyticshttp.RequestInfo{
+				Request:  c.Request,
+				Route:    route,
+				Status:   c.Writer.Status(),
+				Bytes:    max(c.Writer.Size(), 0),
+				Duration: time.Since(start),
+				Err:      err,
+				Panic:    p,
+			})
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		c.Next()
+	}
+}
+
+// Tracker 返回当前请求的 Tracker，事件会继承请求的用户与会话身份
+func Tracker(c *gin.Context) *analyticshttp.Tracker {
+	return analyticshttp.TrackerFromContext(c.Request.Context())
+}
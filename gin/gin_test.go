@@ -0,0 +1,102 @@
+package analyticsgin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	analytics "github.com/difyz9/go-analysis-client"
+	"github.com/difyz9/go-analysis-client/analyticshttp"
+	"github.com/gin-gonic/gin"
+)
+
+// collect 启动事件收集服务器，返回读取已收到事件的函数
+func collect(t *testing.T) (string, func() []analytics.Event) {
+	t.Helper()
+	var mu sync.Mutex
+	var events []analytics.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []analytics.Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		events = append(events, payload.Events...)
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, func() []analytics.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]analytics.Event(nil), events...)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	url, events := collect(t)
+	client := analytics.NewClient(url, "TestApp")
+
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	r.Use(Middleware(client, &analyticshttp.Options{
+		UserFunc: func(r *http.Request) string { return r.Header.Get("X-User") },
+	}))
+	r.GET("/users/:id", func(c *gin.Context) {
+		Tracker(c).Track("user_viewed", map[string]interface{}{"id": c.Param("id")})
+		c.String(http.StatusOK, "ok")
+	})
+	r.GET("/fail", func(c *gin.Context) {
+		c.Error(errors.New("db unavailable"))
+		c.Status(http.StatusServiceUnavailable)
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		panic("gin panic")
+	})
+
+	for _, path := range []string{"/users/42", "/fail", "/panic"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-User", "u-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+	}
+	client.Close()
+
+	var requests []analytics.Event
+	counts := make(map[string]int)
+	for _, e := range events() {
+		counts[e.Name]++
+		if e.Name == analyticshttp.DefaultEventName {
+			requests = append(requests, e)
+		}
+		if e.Properties["user_id"] != "u-1" {
+			t.Errorf("%s event missing user identity: %v", e.Name, e.Properties)
+		}
+	}
+	if counts["user_viewed"] != 1 || counts["error"] != 2 || len(requests) != 3 {
+		t.Fatalf("event counts = %v", counts)
+	}
+
+	want := []struct {
+		route  string
+		status float64
+	}{
+		{"/users/:id", 200},
+		{"/fail", 503},
+		{"/panic", 500},
+	}
+	for i, w := range want {
+		p := requests[i].Properties
+		if p["route"] != w.route || p["status"] != w.status {
+			t.Errorf("request %d = route %v status %v, want %s %v", i, p["route"], p["status"], w.route, w.status)
+		}
+	}
+	if requests[1].Properties["error"] != "db unavailable" {
+		t.Errorf("error = %v", requests[1].Properties["error"])
+	}
+}
@@ -0,0 +1,393 @@
+// Package analyticsgrpc 为 gRPC 提供分析统计拦截器
+//
+//	server := grpc.NewServer(
+//	    grpc.ChainUnaryInterceptor(analyticsgrpc.UnaryServerInterceptor(client)),
+//	    grpc.ChainStreamInterceptor(analyticsgrpc.StreamServerInterceptor(client)),
+//	)
+//
+//	conn, err := grpc.NewClient(target,
+//	    grpc.WithUnaryInterceptor(analyticsgrpc.UnaryClientInterceptor(client)),
+//	    grpc.WithStreamInterceptor(analyticsgrpc.StreamClientInterceptor(client)),
+//	)
+//
+// 每次调用记录一个事件，包括完整方法名、状态码、耗时与消息数。
+// 本包是独立的 Go module，核心 SDK 不依赖 gRPC。
+package analyticsgrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	analytics "github.com/difyz9/go-analysis-client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// 默认事件名称
+const (
+	DefaultServerEventName = "grpc_server_call"
+	DefaultClientEventName = "grpc_client_call"
+)
+
+// config 拦截器配置
+type config struct {
+	eventName   string
+	allow       []string
+	deny        []string
+	userKey     string
+	userFunc    func(ctx context.Context) string
+	extraFields func(ctx context.Context, fullMethod string) map[string]interface{}
+}
+
+// Option 拦截器配置选项
+type Option func(*config)
+
+// WithEventName 设置事件名称
+func WithEventName(name string) Option {
+	return func(c *config) {
+		c.eventName = name
+	}
+}
+
+// WithAllowMethods 仅记录匹配的方法
+//
+// 模式使用 path.Match 语法匹配完整方法名，例如 "/pkg.Service/*"。
+func WithAllowMethods(patterns ...string) Option {
+	return func(c *config) {
+		c.allow = append(c.allow, patterns...)
+	}
+}
+
+// WithDenyMethods 不记录匹配的方法，优先于 WithAllowMethods
+//
+//	analyticsgrpc.WithDenyMethods("/grpc.health.v1.Health/*")
+func WithDenyMethods(patterns ...string) Option {
+	return func(c *config) {
+		c.deny = append(c.deny, patterns...)
+	}
+}
+
+// WithUserMetadataKey 从请求元数据中读取用户 ID 的键，例如 "x-user-id"
+//
+// 服务端读取 incoming metadata，客户端读取 outgoing metadata。
+func WithUserMetadataKey(key string) Option {
+	return func(c *config) {
+		c.userKey = strings.ToLower(key)
+	}
+}
+
+// WithUserFunc 自定义用户 ID 提取，优先于 WithUserMetadataKey
+func WithUserFunc(fn func(ctx context.Context) string) Option {
+	return func(c *config) {
+		c.userFunc = fn
+	}
+}
+
+// WithProperties 返回附加到事件的额外属性
+func WithProperties(fn func(ctx context.Context, fullMethod string) map[string]interface{}) Option {
+	return func(c *config) {
+		c.extraFields = fn
+	}
+}
+
+func newConfig(defaultEvent string, opts []Option) *config {
+	cfg := &config{eventName: defaultEvent}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// shouldRecord 根据允许/拒绝列表判断是否记录该方法
+func (c *config) shouldRecord(fullMethod string) bool {
+	for _, p := range c.deny {
+		if ok, _ := path.Match(p, fullMethod); ok {
+			return false
+		}
+	}
+	if len(c.allow) == 0 {
+		return true
+	}
+	for _, p := range c.allow {
+		if ok, _ := path.Match(p, fullMethod); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// userID 提取调用的用户 ID
+func (c *config) userID(ctx context.Context, incoming bool) string {
+	if c.userFunc != nil {
+		return c.userFunc(ctx)
+	}
+	if c.userKey == "" {
+		return ""
+	}
+	var md metadata.MD
+	var ok bool
+	if incoming {
+		md, ok = metadata.FromIncomingContext(ctx)
+	} else {
+		md, ok = metadata.FromOutgoingContext(ctx)
+	}
+	if !ok {
+		return ""
+	}
+	if vals := md.Get(c.userKey); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// requestContext 为服务端调用创建请求级 context，下游 TrackContext 自动带上方法与用户
+func (c *config) requestContext(ctx context.Context, fullMethod string) context.Context {
+	props := map[string]interface{}{"grpc_method": fullMethod}
+	if user := c.userID(ctx, true); user != "" {
+		props["user_id"] = user
+	}
+	return analytics.ContextWithProperties(ctx, props)
+}
+
+// record 记录一次调用
+func (c *config) record(client *analytics.Client, ctx context.Context, fullMethod string, kind string,
+	start time.Time, err error, sent, received int64) {
+	service, method := splitMethod(fullMethod)
+	st := status.Convert(err)
+
+	props := map[string]interface{}{
+		"full_method":       fullMethod,
+		"service":           service,
+		"method":            method,
+		"type":              kind,
+		"code":              st.Code().String(),
+		"duration_ms":       time.Since(start).Milliseconds(),
+		"messages_sent":     sent,
+		"messages_received": received,
+	}
+	if err != nil {
+		props["error"] = st.Message()
+	}
+	if c.extraFields != nil {
+		for k, v := range c.extraFields(ctx, fullMethod) {
+			props[k] = v
+		}
+	}
+	client.TrackContext(ctx, c.eventName, props)
+}
+
+// splitMethod 将 "/pkg.Service/Method" 拆分为服务名与方法名
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndexByte(fullMethod, '/'); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "", fullMethod
+}
+
+// streamKind 返回流类型描述
+func streamKind(clientStream, serverStream bool) string {
+	switch {
+	case clientStream && serverStream:
+		return "bidi_stream"
+	case clientStream:
+		return "client_stream"
+	case serverStream:
+		return "server_stream"
+	}
+	return "unary"
+}
+
+// =============================================================================
+// 服务端拦截器
+// =============================================================================
+
+// UnaryServerInterceptor 返回记录一元调用的服务端拦截器
+func UnaryServerInterceptor(client *analytics.Client, opts ...Option) grpc.UnaryServerInterceptor {
+	cfg := newConfig(DefaultServerEventName, opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !cfg.shouldRecord(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		ctx = cfg.requestContext(ctx, info.FullMethod)
+		resp, err := handler(ctx, req)
+		var sent int64
+		if err == nil {
+			sent = 1
+		}
+		cfg.record(client, ctx, info.FullMethod, "unary", start, err, sent, 1)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor 返回记录流式调用的服务端拦截器
+func StreamServerInterceptor(client *analytics.Client, opts ...Option) grpc.StreamServerInterceptor {
+	cfg := newConfig(DefaultServerEventName, opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !cfg.shouldRecord(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		start := time.Now()
+		wrapped := &serverStream{ServerStream: ss, ctx: cfg.requestContext(ss.Context(), info.FullMethod)}
+		err := handler(srv, wrapped)
+		cfg.record(client, wrapped.ctx, info.FullMethod, streamKind(info.IsClientStream, info.IsServerStream),
+			start, err, wrapped.sent.Load(), wrapped.received.Load())
+		return err
+	}
+}
+
+// serverStream 统计消息数并替换 context
+type serverStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	sent     atomic.Int64
+	received atomic.Int64
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+	return err
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received.Add(1)
+	}
+	return err
+}
+
+// =============================================================================
+// 客户端拦截器
+// =============================================================================
+
+// UnaryClientInterceptor 返回记录一元调用的客户端拦截器
+func UnaryClientInterceptor(client *analytics.Client, opts ...Option) grpc.UnaryClientInterceptor {
+	cfg := newConfig(DefaultClientEventName, opts)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if !cfg.shouldRecord(method) {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		var received int64
+		if err == nil {
+			received = 1
+		}
+		cfg.record(client, clientContext(cfg, ctx), method, "unary", start, err, 1, received)
+		return err
+	}
+}
+
+// StreamClientInterceptor 返回记录流式调用的客户端拦截器
+//
+// 流在收到 io.EOF、出错或 context 结束时记录；没有服务端流的调用（一元响应，
+// 例如 CloseAndRecv）在成功收到响应时记录。
+func StreamClientInterceptor(client *analytics.Client, opts ...Option) grpc.StreamClientInterceptor {
+	cfg := newConfig(DefaultClientEventName, opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if !cfg.shouldRecord(method) {
+			return streamer(ctx, desc, cc, method, callOpts...)
+		}
+		start := time.Now()
+		kind := streamKind(desc.ClientStreams, desc.ServerStreams)
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			cfg.record(client, clientContext(cfg, ctx), method, kind, start, err, 0, 0)
+			return nil, err
+		}
+
+		stream := &clientStream{ClientStream: cs, singleResponse: !desc.ServerStreams}
+		stream.finish = func(err error) {
+			cfg.record(client, clientContext(cfg, ctx), method, kind, start, err, stream.sent.Load(), stream.received.Load())
+		}
+		// 调用方可能在取消后不再读取流，由 context 结束兜底记录。
+		// ctx 已结束时回调会立即在另一个 goroutine 中执行，stop 需在锁内赋值
+		stream.mu.Lock()
+		stream.stop = context.AfterFunc(ctx, func() {
+			stream.end(status.FromContextError(ctx.Err()).Err())
+		})
+		stream.mu.Unlock()
+		return stream, nil
+	}
+}
+
+// clientContext 为客户端调用附加用户 ID
+func clientContext(cfg *config, ctx context.Context) context.Context {
+	if user := cfg.userID(ctx, false); user != "" {
+		return analytics.ContextWithProperties(ctx, map[string]interface{}{"user_id": user})
+	}
+	return ctx
+}
+
+// clientStream 统计消息数，并在流结束时记录一次
+type clientStream struct {
+	grpc.ClientStream
+	singleResponse bool // 服务端只返回一条消息，收到后流即结束
+	sent           atomic.Int64
+	received       atomic.Int64
+	done           atomic.Bool
+	finish         func(err error)
+
+	mu   sync.Mutex
+	stop func() bool // 取消 context 结束时的记录
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	} else if !isEOF(err) {
+		s.end(err)
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		s.received.Add(1)
+		if s.singleResponse {
+			s.end(nil)
+		}
+	case isEOF(err):
+		s.end(nil)
+	default:
+		s.end(err)
+	}
+	return err
+}
+
+// end 仅记录一次
+func (s *clientStream) end(err error) {
+	if s.done.CompareAndSwap(false, true) {
+		s.mu.Lock()
+		stop := s.stop
+		s.mu.Unlock()
+		if stop != nil {
+			stop()
+		}
+		s.finish(err)
+	}
+}
+
+// isEOF 判断是否为流正常结束
+func isEOF(err error) bool {
+	return errors.Is(err, io.EOF)
+}
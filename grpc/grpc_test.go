@@ -0,0 +1,275 @@
+package analyticsgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	analytics "github.com/difyz9/go-analysis-client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// collect 启动事件收集服务器，返回读取已收到事件的函数
+func collect(t *testing.T) (string, func() []analytics.Event) {
+	t.Helper()
+	var mu sync.Mutex
+	var events []analytics.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []analytics.Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		events = append(events, payload.Events...)
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, func() []analytics.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]analytics.Event(nil), events...)
+	}
+}
+
+// userEchoHealth 在 Check 中发送一个带请求 context 的事件，用于验证下游 TrackContext
+type userEchoHealth struct {
+	*health.Server
+	client *analytics.Client
+}
+
+func (h *userEchoHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	h.client.TrackContext(ctx, "check_handled", nil)
+	return h.Server.Check(ctx, req)
+}
+
+// startServer 启动 bufconn gRPC 服务器并返回客户端连接
+func startServer(t *testing.T, serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) (*grpc.ClientConn, *health.Server) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(serverOpts...)
+	hs := health.NewServer()
+	hs.SetServingStatus("ok", healthpb.HealthCheckResponse_SERVING)
+	return startWith(t, lis, srv, hs, dialOpts...), hs
+}
+
+func startWith(t *testing.T, lis *bufconn.Listener, srv *grpc.Server, impl healthpb.HealthServer, dialOpts ...grpc.DialOption) *grpc.ClientConn {
+	healthpb.RegisterHealthServer(srv, impl)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	dialOpts = append(dialOpts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufnet", dialOpts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestServerInterceptors(t *testing.T) {
+	url, events := collect(t)
+	client := analytics.NewClient(url, "TestApp")
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor(client, WithUserMetadataKey("X-User-ID"))),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor(client, WithUserMetadataKey("X-User-ID"))),
+	)
+	hs := health.NewServer()
+	hs.SetServingStatus("ok", healthpb.HealthCheckResponse_SERVING)
+	conn := startWith(t, lis, srv, &userEchoHealth{Server: hs, client: client})
+	hc := healthpb.NewHealthClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-user-id", "u-1")
+	if _, err := hc.Check(ctx, &healthpb.HealthCheckRequest{Service: "ok"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hc.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Check(missing) error = %v", err)
+	}
+
+	// 服务端流：接收一次状态后取消
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, err := hc.Watch(streamCtx, &healthpb.HealthCheckRequest{Service: "ok"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	srv.GracefulStop()
+	client.Close()
+
+	var calls []analytics.Event
+	handled := 0
+	for _, e := range events() {
+		switch e.Name {
+		case DefaultServerEventName:
+			calls = append(calls, e)
+		case "check_handled":
+			handled++
+			if e.Properties["user_id"] != "u-1" || e.Properties["grpc_method"] != "/grpc.health.v1.Health/Check" {
+				t.Errorf("downstream event missing request context: %v", e.Properties)
+			}
+		}
+	}
+	if handled != 2 || len(calls) != 3 {
+		t.Fatalf("handled=%d calls=%d, want 2 and 3", handled, len(calls))
+	}
+
+	want := []struct {
+		method, code, kind string
+		sent               float64
+	}{
+		{"Check", "OK", "unary", 1},
+		{"Check", "NotFound", "unary", 0},
+		{"Watch", "Canceled", "server_stream", 1},
+	}
+	for i, w := range want {
+		p := calls[i].Properties
+		if p["method"] != w.method || p["code"] != w.code || p["type"] != w.kind || p["messages_sent"] != w.sent {
+			t.Errorf("call %d = %v, want %+v", i, p, w)
+		}
+		if p["service"] != "grpc.health.v1.Health" || p["user_id"] != "u-1" {
+			t.Errorf("call %d service/user = %v/%v", i, p["service"], p["user_id"])
+		}
+	}
+}
+
+func TestClientInterceptorsAndFilters(t *testing.T) {
+	url, events := collect(t)
+	client := analytics.NewClient(url, "TestApp", analytics.WithFlushInterval(10*time.Millisecond))
+
+	conn, _ := startServer(t, nil,
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(client,
+			WithDenyMethods("/grpc.reflection.*/*"),
+			WithUserMetadataKey("x-user-id"),
+		)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(client, WithAllowMethods("/grpc.health.v1.*/*"))),
+	)
+	hc := healthpb.NewHealthClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-user-id", "u-2")
+	hc.Check(ctx, &healthpb.HealthCheckRequest{Service: "ok"})
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, err := hc.Watch(streamCtx, &healthpb.HealthCheckRequest{Service: "ok"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.Recv()
+	// 取消后不再读取流，由 context 结束触发记录
+	cancel()
+	got := waitEvents(t, events, 2)
+	client.Close()
+
+	if len(got) != 2 {
+		t.Fatalf("received %d events, want 2: %+v", len(got), got)
+	}
+	if p := got[0].Properties; got[0].Name != DefaultClientEventName || p["method"] != "Check" || p["user_id"] != "u-2" {
+		t.Errorf("unary client event = %v", p)
+	}
+	if p := got[1].Properties; p["method"] != "Watch" || p["code"] != "Canceled" || p["messages_received"] != float64(1) {
+		t.Errorf("stream client event = %v", p)
+	}
+}
+
+// waitEvents 等待收集到 n 个事件，客户端需使用较短的刷新间隔
+func waitEvents(t *testing.T, events func() []analytics.Event, n int) []analytics.Event {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := events()
+		if len(got) >= n || time.Now().After(deadline) {
+			return got
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClientStreamUnaryResponse(t *testing.T) {
+	url, events := collect(t)
+	client := analytics.NewClient(url, "TestApp", analytics.WithFlushInterval(10*time.Millisecond))
+	conn, _ := startServer(t, nil, grpc.WithStreamInterceptor(StreamClientInterceptor(client)))
+
+	// 没有服务端流的调用在收到响应后结束，调用方不会再读到 io.EOF
+	desc := &grpc.StreamDesc{StreamName: "Check", ClientStreams: true}
+	cs, err := conn.NewStream(context.Background(), desc, "/grpc.health.v1.Health/Check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.SendMsg(&healthpb.HealthCheckRequest{Service: "ok"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.RecvMsg(new(healthpb.HealthCheckResponse)); err != nil {
+		t.Fatal(err)
+	}
+
+	got := waitEvents(t, events, 1)
+	client.Close()
+	if len(got) != 1 {
+		t.Fatalf("received %d events, want 1", len(got))
+	}
+	p := got[0].Properties
+	if p["type"] != "client_stream" || p["code"] != "OK" || p["messages_sent"] != float64(1) || p["messages_received"] != float64(1) {
+		t.Errorf("stream client event = %v", p)
+	}
+}
+
+// TestClientStreamCanceledBeforeReturn 测试 ctx 在创建流时已结束的情况
+func TestClientStreamCanceledBeforeReturn(t *testing.T) {
+	url, events := collect(t)
+	client := analytics.NewClient(url, "TestApp", analytics.WithFlushInterval(10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cancel()
+		return nil, nil
+	}
+	interceptor := StreamClientInterceptor(client)
+	desc := &grpc.StreamDesc{ServerStreams: true}
+	if _, err := interceptor(ctx, desc, nil, "/pkg.Service/Watch", streamer); err != nil {
+		t.Fatal(err)
+	}
+
+	got := waitEvents(t, events, 1)
+	client.Close()
+	if len(got) != 1 || got[0].Properties["code"] != "Canceled" {
+		t.Errorf("events = %+v, want one Canceled call", got)
+	}
+}
+
+func TestShouldRecord(t *testing.T) {
+	cfg := newConfig("x", []Option{
+		WithAllowMethods("/pkg.Orders/*"),
+		WithDenyMethods("/pkg.Orders/Ping"),
+	})
+	for method, want := range map[string]bool{
+		"/pkg.Orders/Create": true,
+		"/pkg.Orders/Ping":   false,
+		"/pkg.Users/Get":     false,
+	} {
+		if got := cfg.shouldRecord(method); got != want {
+			t.Errorf("shouldRecord(%s) = %v, want %v", method, got, want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package analytics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// eventCollector 收集发送到 /api/events/batch 的事件的测试服务器
+type eventCollector struct {
+	*httptest.Server
+
+	// status 非零时以该状态码响应且不记录事件，用于模拟服务端故障
+	status   atomic.Int32
+	requests atomic.Int32
+
+	mu      sync.Mutex
+	batches [][]Event
+}
+
+// newEventCollector 创建事件收集服务器，测试结束时自动关闭
+func newEventCollector(t *testing.T) *eventCollector {
+	t.Helper()
+	ec := &eventCollector{}
+	ec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ec.requests.Add(1)
+		if status := ec.status.Load(); status != 0 {
+			w.WriteHeader(int(status))
+			return
+		}
+		var payload struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		ec.mu.Lock()
+		ec.batches = append(ec.batches, payload.Events)
+		ec.mu.Unlock()
+	}))
+	t.Cleanup(ec.Close)
+	return ec
+}
+
+// Events 返回收到的所有事件
+func (ec *eventCollector) Events() []Event {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	var all []Event
+	for _, b := range ec.batches {
+		all = append(all, b...)
+	}
+	return all
+}
+
+// Batches 返回收到的批次数
+func (ec *eventCollector) Batches() int {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	return len(ec.batches)
+}
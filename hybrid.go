@@ -0,0 +1,297 @@
+package analytics
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// =============================================================================
+// 公钥信封加密（混合加密）
+// =============================================================================
+//
+// 客户端只持有服务端公钥：每个批次生成一次性数据密钥，使用 AES-256-GCM
+// 加密批次数据，再用服务端公钥包装数据密钥。即使客户端二进制被逆向，
+// 也无法解密其他客户端的流量。
+
+// 混合加密算法标识
+const (
+	// HybridAlgX25519 X25519 密钥协商 + HKDF-SHA256 派生包装密钥 + AES-256-GCM
+	HybridAlgX25519 = "X25519-HKDF-SHA256+A256GCM"
+
+	// HybridAlgRSAOAEP RSA-OAEP(SHA-256) 包装数据密钥 + AES-256-GCM
+	HybridAlgRSAOAEP = "RSA-OAEP-256+A256GCM"
+)
+
+// hybridInfo HKDF 与 OAEP 使用的上下文标签
+var hybridInfo = []byte("go-analysis-client hybrid envelope v1")
+
+// HybridEnvelope 混合加密请求体
+type HybridEnvelope struct {
+	Alg          string `json:"alg"`
+	KeyID        string `json:"kid,omitempty"`
+	EphemeralKey string `json:"epk,omitempty"` // X25519 临时公钥（Base64）
+	WrappedKey   string `json:"ek"`            // 被包装的数据密钥（Base64）
+	Nonce        string `json:"iv"`            // 数据加密使用的 GCM nonce（Base64）
+	Data         string `json:"data"`          // 密文（Base64）
+}
+
+// WithPublicKeyEncryption 启用公钥信封加密
+//
+// publicKey 支持 *ecdh.PublicKey（X25519）与 *rsa.PublicKey（建议 >= 2048 位）。
+// keyID 会写入信封，便于服务端在多把私钥间选择，可为空。
+//
+// 服务端使用 DecryptHybridEnvelope 解密。
+func WithPublicKeyEncryption(publicKey crypto.PublicKey, keyID string) ClientOption {
+	return func(c *Client) {
+		c.encryption = &EncryptionConfig{
+			Enabled:     true,
+			PublicKey:   publicKey,
+			PublicKeyID: keyID,
+		}
+	}
+}
+
+// ParsePublicKeyPEM 解析 PEM 编码的 PKIX 公钥（X25519 或 RSA）
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, newClientError("ParsePublicKeyPEM", fmt.Errorf("%w: no PEM block found", ErrInvalidKey))
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, newClientError("ParsePublicKeyPEM", fmt.Errorf("%w: %v", ErrInvalidKey, err))
+	}
+	return pub, nil
+}
+
+// ParsePrivateKeyPEM 解析 PEM 编码的 PKCS#8 私钥（X25519 或 RSA）
+func ParsePrivateKeyPEM(data []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, newClientError("ParsePrivateKeyPEM", fmt.Errorf("%w: no PEM block found", ErrInvalidKey))
+	}
+	priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, newClientError("ParsePrivateKeyPEM", fmt.Errorf("%w: %v", ErrInvalidKey, err))
+	}
+	return priv, nil
+}
+
+// HybridEncrypt 使用服务端公钥对数据进行信封加密
+func HybridEncrypt(publicKey crypto.PublicKey, keyID string, plaintext []byte) (*HybridEnvelope, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	env := &HybridEnvelope{KeyID: keyID}
+
+	switch pub := publicKey.(type) {
+	case *ecdh.PublicKey:
+		if pub.Curve() != ecdh.X25519() {
+			return nil, fmt.Errorf("%w: only X25519 ECDH keys are supported", ErrInvalidKey)
+		}
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		shared, err := ephemeral.ECDH(pub)
+		if err != nil {
+			return nil, err
+		}
+		kek := deriveWrapKey(shared, ephemeral.PublicKey().Bytes(), pub.Bytes())
+		wrapped, err := gcmSeal(kek, dataKey, nil)
+		if err != nil {
+			return nil, err
+		}
+		env.Alg = HybridAlgX25519
+		env.EphemeralKey = base64.StdEncoding.EncodeToString(ephemeral.PublicKey().Bytes())
+		env.WrappedKey = base64.StdEncoding.EncodeToString(wrapped)
+
+	case *rsa.PublicKey:
+		wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, dataKey, hybridInfo)
+		if err != nil {
+			return nil, err
+		}
+		env.Alg = HybridAlgRSAOAEP
+		env.WrappedKey = base64.StdEncoding.EncodeToString(wrapped)
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported public key type %T", ErrInvalidKey, publicKey)
+	}
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := gcm.Seal(nil, nonce, plaintext, env.additionalData())
+
+	env.Nonce = base64.StdEncoding.EncodeToString(nonce)
+	env.Data = base64.StdEncoding.EncodeToString(ciphertext)
+	return env, nil
+}
+
+// DecryptHybridEnvelope 使用服务端私钥解密混合加密请求体
+//
+// 供采集服务端使用：
+//
+//	priv, _ := analytics.ParsePrivateKeyPEM(pemBytes)
+//	plaintext, err := analytics.DecryptHybridEnvelope(priv, body)
+//
+// privateKey 支持 *ecdh.PrivateKey（X25519）与 *rsa.PrivateKey。
+func DecryptHybridEnvelope(privateKey crypto.PrivateKey, body []byte) ([]byte, error) {
+	var env HybridEnvelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, newClientError("DecryptHybridEnvelope", fmt.Errorf("%w: %v", ErrUnmarshalFailed, err))
+	}
+	plaintext, err := env.Decrypt(privateKey)
+	if err != nil {
+		return nil, newClientError("DecryptHybridEnvelope", fmt.Errorf("%w: %v", ErrDecryptionFailed, err))
+	}
+	return plaintext, nil
+}
+
+// Decrypt 使用私钥解开数据密钥并解密数据
+func (e *HybridEnvelope) Decrypt(privateKey crypto.PrivateKey) ([]byte, error) {
+	wrapped, err := base64.StdEncoding.DecodeString(e.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataKey []byte
+	switch e.Alg {
+	case HybridAlgX25519:
+		priv, ok := privateKey.(*ecdh.PrivateKey)
+		if !ok || priv.Curve() != ecdh.X25519() {
+			return nil, fmt.Errorf("%w: %s requires an X25519 private key", ErrInvalidKey, e.Alg)
+		}
+		epkBytes, err := base64.StdEncoding.DecodeString(e.EphemeralKey)
+		if err != nil {
+			return nil, err
+		}
+		epk, err := ecdh.X25519().NewPublicKey(epkBytes)
+		if err != nil {
+			return nil, err
+		}
+		shared, err := priv.ECDH(epk)
+		if err != nil {
+			return nil, err
+		}
+		kek := deriveWrapKey(shared, epkBytes, priv.PublicKey().Bytes())
+		if dataKey, err = gcmOpen(kek, wrapped, nil); err != nil {
+			return nil, err
+		}
+
+	case HybridAlgRSAOAEP:
+		priv, ok := privateKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s requires an RSA private key", ErrInvalidKey, e.Alg)
+		}
+		if dataKey, err = rsa.DecryptOAEP(sha256.New(), nil, priv, wrapped, hybridInfo); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported envelope algorithm %q", e.Alg)
+	}
+
+	nonce, err := base64.StdEncoding.DecodeString(e.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(e.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce size")
+	}
+	return gcm.Open(nil, nonce, ciphertext, e.additionalData())
+}
+
+// additionalData 将算法与密钥 ID 绑定到 GCM 认证数据中，防止信封头被篡改
+func (e *HybridEnvelope) additionalData() []byte {
+	return []byte(e.Alg + "|" + e.KeyID)
+}
+
+// deriveWrapKey 使用 HKDF-SHA256 从 ECDH 共享密钥派生 32 字节包装密钥
+//
+// salt 为临时公钥与接收方公钥的拼接，info 为固定上下文标签。
+func deriveWrapKey(shared, ephemeralPub, recipientPub []byte) []byte {
+	salt := make([]byte, 0, len(ephemeralPub)+len(recipientPub))
+	salt = append(salt, ephemeralPub...)
+	salt = append(salt, recipientPub...)
+
+	// HKDF-Extract
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(shared)
+	prk := extract.Sum(nil)
+
+	// HKDF-Expand（仅需一个输出块）
+	expand := hmac.New(sha256.New, prk)
+	expand.Write(hybridInfo)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)
+}
+
+// gcmSeal 使用随机 nonce 进行 AES-GCM 加密，输出 nonce||ciphertext
+func gcmSeal(key, plaintext, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// gcmOpen 解密 gcmSeal 的输出
+func gcmOpen(key, sealed, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("sealed data too short")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], additionalData)
+}
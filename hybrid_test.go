@@ -0,0 +1,132 @@
+package analytics
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHybridEncryptionRoundTrip 测试 X25519 与 RSA-OAEP 信封加密的往返
+func TestHybridEncryptionRoundTrip(t *testing.T) {
+	x25519Key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		pub     crypto.PublicKey
+		priv    crypto.PrivateKey
+		wantAlg string
+	}{
+		{"X25519", x25519Key.PublicKey(), x25519Key, HybridAlgX25519},
+		{"RSA-OAEP", &rsaKey.PublicKey, rsaKey, HybridAlgRSAOAEP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan []byte, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				received <- body
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL, "TestApp", WithPublicKeyEncryption(tt.pub, "srv-1"))
+			defer client.Close()
+
+			if err := client.sendEvents(context.Background(), []*Event{{Name: "sealed", Timestamp: time.Now().Unix()}}); err != nil {
+				t.Fatalf("sendEvents() error = %v", err)
+			}
+
+			body := <-received
+			var env HybridEnvelope
+			json.Unmarshal(body, &env)
+			if env.Alg != tt.wantAlg || env.KeyID != "srv-1" {
+				t.Errorf("envelope alg=%q kid=%q, want %q srv-1", env.Alg, env.KeyID, tt.wantAlg)
+			}
+
+			plaintext, err := DecryptHybridEnvelope(tt.priv, body)
+			if err != nil {
+				t.Fatalf("DecryptHybridEnvelope() error = %v", err)
+			}
+			var payload struct {
+				Events []Event `json:"events"`
+			}
+			json.Unmarshal(plaintext, &payload)
+			if len(payload.Events) != 1 || payload.Events[0].Name != "sealed" {
+				t.Errorf("unexpected payload: %s", plaintext)
+			}
+
+			// 篡改信封头应导致认证失败
+			env.KeyID = "srv-2"
+			tampered, _ := json.Marshal(env)
+			if _, err := DecryptHybridEnvelope(tt.priv, tampered); err == nil {
+				t.Error("DecryptHybridEnvelope() with tampered kid should fail")
+			}
+		})
+	}
+}
+
+// TestHybridWrongPrivateKey 测试使用错误私钥解密失败
+func TestHybridWrongPrivateKey(t *testing.T) {
+	key, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	other, _ := ecdh.X25519().GenerateKey(rand.Reader)
+
+	env, err := HybridEncrypt(key.PublicKey(), "", []byte("data"))
+	if err != nil {
+		t.Fatalf("HybridEncrypt() error = %v", err)
+	}
+	if _, err := env.Decrypt(other); err == nil {
+		t.Error("Decrypt() with wrong key should fail")
+	}
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := env.Decrypt(rsaKey); err == nil {
+		t.Error("Decrypt() with mismatched key type should fail")
+	}
+}
+
+// TestParseKeyPEM 测试 PEM 公私钥解析
+func TestParseKeyPEM(t *testing.T) {
+	key, _ := ecdh.X25519().GenerateKey(rand.Reader)
+
+	pubDER, _ := x509.MarshalPKIXPublicKey(key.PublicKey())
+	privDER, _ := x509.MarshalPKCS8PrivateKey(key)
+
+	pub, err := ParsePublicKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	if err != nil {
+		t.Fatalf("ParsePublicKeyPEM() error = %v", err)
+	}
+	priv, err := ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))
+	if err != nil {
+		t.Fatalf("ParsePrivateKeyPEM() error = %v", err)
+	}
+
+	env, err := HybridEncrypt(pub, "", []byte("pem"))
+	if err != nil {
+		t.Fatalf("HybridEncrypt() error = %v", err)
+	}
+	got, err := env.Decrypt(priv)
+	if err != nil || string(got) != "pem" {
+		t.Errorf("Decrypt() = %q, %v", got, err)
+	}
+
+	if _, err := ParsePublicKeyPEM([]byte("not pem")); err == nil {
+		t.Error("ParsePublicKeyPEM() with invalid input should fail")
+	}
+}
@@ -90,8 +90,8 @@ func ExampleClient_TrackAppExit() {
 	// Output: 应用退出事件已记录
 }
 
-// ExampleFullAppLifecycle 演示完整的应用生命周期统计
-func ExampleFullAppLifecycle() {
+// Example_fullAppLifecycle 演示完整的应用生命周期统计
+func Example_fullAppLifecycle() {
 	// 1. 初始化客户端
 	client := analytics.NewClient(
 		"http://localhost:8080",
@@ -129,9 +129,9 @@ func ExampleFullAppLifecycle() {
 	// Output: 完整生命周期事件已记录
 }
 
-// ExampleWithAESEncryption 演示 AES 加密通讯
+// ExampleNewAESClient 演示 AES 加密通讯
 // 注意：加密功能应使用 NewAESClient，而不是 NewClient + WithEncryption
-func ExampleWithAESEncryption() {
+func ExampleNewAESClient() {
 	// 创建 AES 加密客户端
 	aesClient := analytics.NewAESClient(
 		"http://localhost:8080",
@@ -152,6 +152,4 @@ func ExampleWithAESEncryption() {
 	}
 
 	fmt.Println("AES 加密通讯已启用")
-	
-	// Output: AES 加密通讯已启用
 }
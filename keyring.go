@@ -0,0 +1,192 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// =============================================================================
+// Keyring - 支持轮换的加密密钥环
+// =============================================================================
+
+// EncryptedEnvelope 加密请求体
+//
+// 使用单一密钥（WithEncryption）时 KeyID 为空，与旧版服务端保持兼容；
+// 使用密钥环（WithEncryptionKeyring）时 KeyID 为加密所用密钥的 ID。
+type EncryptedEnvelope struct {
+	KeyID string `json:"kid,omitempty"`
+	Data  string `json:"data"`
+}
+
+// Keyring 加密密钥环
+//
+// 密钥环中可以保存多个带 ID 的密钥，其中一个被标记为活动密钥用于加密，
+// 其余密钥仅用于解密。轮换密钥的推荐流程：
+//
+//  1. 服务端先将新密钥加入密钥环（仍可解密旧密钥加密的数据）
+//  2. 客户端发布新版本，将新密钥设为活动密钥
+//  3. 旧客户端全部下线后，服务端移除旧密钥
+//
+// 使用单一密钥（WithEncryption）的旧客户端不携带 kid，服务端用默认密钥解密，
+// 默认密钥为第一个加入的密钥，不随 SetActive 改变，可通过 SetDefault 修改。
+//
+// Keyring 可以安全地并发使用。
+type Keyring struct {
+	mu     sync.RWMutex
+	keys   map[string][]byte
+	active string
+	legacy string // 不带 kid 的信封使用的默认密钥
+}
+
+// NewKeyring 创建空的密钥环
+func NewKeyring() *Keyring {
+	return &Keyring{
+		keys: make(map[string][]byte),
+	}
+}
+
+// Add 添加密钥，若密钥环中尚无活动密钥，则新密钥成为活动密钥与默认密钥
+func (k *Keyring) Add(keyID, secretKey string) error {
+	if keyID == "" || secretKey == "" {
+		return newClientError("Keyring.Add", ErrInvalidKey)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[keyID] = []byte(secretKey)
+	if k.active == "" {
+		k.active = keyID
+	}
+	if k.legacy == "" {
+		k.legacy = keyID
+	}
+	return nil
+}
+
+// SetActive 将指定 ID 的密钥设为活动密钥
+func (k *Keyring) SetActive(keyID string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[keyID]; !ok {
+		return newClientError("Keyring.SetActive", fmt.Errorf("%w: %s", ErrUnknownKeyID, keyID))
+	}
+	k.active = keyID
+	return nil
+}
+
+// SetDefault 设置解密不带 kid 的信封时使用的密钥
+func (k *Keyring) SetDefault(keyID string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[keyID]; !ok {
+		return newClientError("Keyring.SetDefault", fmt.Errorf("%w: %s", ErrUnknownKeyID, keyID))
+	}
+	k.legacy = keyID
+	return nil
+}
+
+// Remove 移除密钥，不能移除当前活动密钥
+func (k *Keyring) Remove(keyID string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if keyID == k.active {
+		return newClientError("Keyring.Remove", fmt.Errorf("%w: cannot remove active key %s", ErrInvalidKey, keyID))
+	}
+	delete(k.keys, keyID)
+	return nil
+}
+
+// ActiveKeyID 返回活动密钥 ID
+func (k *Keyring) ActiveKeyID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active
+}
+
+// KeyIDs 返回所有密钥 ID（已排序）
+func (k *Keyring) KeyIDs() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Key 返回指定 ID 的密钥
+func (k *Keyring) Key(keyID string) ([]byte, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[keyID]
+	return key, ok
+}
+
+// Encrypt 使用活动密钥加密数据，返回加密信封
+func (k *Keyring) Encrypt(plaintext []byte) (*EncryptedEnvelope, error) {
+	k.mu.RLock()
+	keyID := k.active
+	key := k.keys[keyID]
+	k.mu.RUnlock()
+
+	if keyID == "" {
+		return nil, newClientError("Keyring.Encrypt", fmt.Errorf("%w: no active key", ErrInvalidKey))
+	}
+
+	data, err := AESEncrypt(key, plaintext)
+	if err != nil {
+		return nil, newClientError("Keyring.Encrypt", fmt.Errorf("%w: %v", ErrEncryptionFailed, err))
+	}
+	return &EncryptedEnvelope{KeyID: keyID, Data: data}, nil
+}
+
+// Decrypt 根据密钥 ID 选择密钥并解密
+//
+// keyID 为空时使用默认密钥（见 SetDefault），以兼容未携带 kid 的旧客户端；
+// 轮换活动密钥不影响旧客户端数据的解密。
+func (k *Keyring) Decrypt(keyID, ciphertextBase64 string) ([]byte, error) {
+	if keyID == "" {
+		k.mu.RLock()
+		keyID = k.legacy
+		k.mu.RUnlock()
+	}
+	key, ok := k.Key(keyID)
+	if !ok {
+		return nil, newClientError("Keyring.Decrypt", fmt.Errorf("%w: %s", ErrUnknownKeyID, keyID))
+	}
+
+	plaintext, err := AESDecrypt(key, ciphertextBase64)
+	if err != nil {
+		return nil, newClientError("Keyring.Decrypt", fmt.Errorf("%w: %v", ErrDecryptionFailed, err))
+	}
+	return plaintext, nil
+}
+
+// DecryptEnvelope 解析加密请求体并使用密钥环解密
+//
+// 供服务端使用：
+//
+//	plaintext, err := analytics.DecryptEnvelope(keyring, body)
+func DecryptEnvelope(k *Keyring, body []byte) ([]byte, error) {
+	var env EncryptedEnvelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, newClientError("DecryptEnvelope", fmt.Errorf("%w: %v", ErrUnmarshalFailed, err))
+	}
+	return k.Decrypt(env.KeyID, env.Data)
+}
+
+// WithEncryptionKeyring 使用密钥环启用 AES 加密传输
+//
+// 每个批次使用密钥环的活动密钥加密，并在请求体中携带 kid，
+// 服务端据此选择解密密钥，从而实现无需全量同步切换的密钥轮换。
+func WithEncryptionKeyring(keyring *Keyring) ClientOption {
+	return func(c *Client) {
+		c.encryption = &EncryptionConfig{
+			Enabled: true,
+			Keyring: keyring,
+		}
+	}
+}
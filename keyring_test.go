@@ -0,0 +1,150 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestKeyringRotation 测试密钥轮换前后的数据都能被正确解密
+func TestKeyringRotation(t *testing.T) {
+	kr := NewKeyring()
+	if err := kr.Add("v1", "0123456789abcdef0123456789abcdef"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	oldEnv, err := kr.Encrypt([]byte("old"))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if oldEnv.KeyID != "v1" {
+		t.Errorf("KeyID = %q, want v1", oldEnv.KeyID)
+	}
+
+	kr.Add("v2", "fedcba9876543210fedcba9876543210")
+	if err := kr.SetActive("v2"); err != nil {
+		t.Fatalf("SetActive() error = %v", err)
+	}
+	newEnv, _ := kr.Encrypt([]byte("new"))
+	if newEnv.KeyID != "v2" {
+		t.Errorf("KeyID = %q, want v2", newEnv.KeyID)
+	}
+
+	for _, tc := range []struct {
+		env  *EncryptedEnvelope
+		want string
+	}{{oldEnv, "old"}, {newEnv, "new"}} {
+		body, _ := json.Marshal(tc.env)
+		got, err := DecryptEnvelope(kr, body)
+		if err != nil {
+			t.Fatalf("DecryptEnvelope(%s) error = %v", tc.env.KeyID, err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("DecryptEnvelope(%s) = %q, want %q", tc.env.KeyID, got, tc.want)
+		}
+	}
+
+	if err := kr.Remove("v2"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Remove(active) error = %v, want ErrInvalidKey", err)
+	}
+	kr.Remove("v1")
+	body, _ := json.Marshal(oldEnv)
+	if _, err := DecryptEnvelope(kr, body); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("DecryptEnvelope(removed key) error = %v, want ErrUnknownKeyID", err)
+	}
+	if err := kr.SetActive("missing"); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("SetActive(missing) error = %v, want ErrUnknownKeyID", err)
+	}
+}
+
+// TestKeyringLegacyEnvelope 测试不带 kid 的旧格式信封使用默认密钥解密
+func TestKeyringLegacyEnvelope(t *testing.T) {
+	key := "0123456789abcdef0123456789abcdef"
+	kr := NewKeyring()
+	kr.Add("v1", key)
+
+	data, _ := AESEncrypt([]byte(key), []byte("legacy"))
+	body, _ := json.Marshal(map[string]string{"data": data})
+
+	got, err := DecryptEnvelope(kr, body)
+	if err != nil {
+		t.Fatalf("DecryptEnvelope() error = %v", err)
+	}
+	if string(got) != "legacy" {
+		t.Errorf("DecryptEnvelope() = %q, want legacy", got)
+	}
+}
+
+// TestKeyringLegacyEnvelopeAfterRotation 测试轮换活动密钥后仍能解密不带 kid 的旧信封
+func TestKeyringLegacyEnvelopeAfterRotation(t *testing.T) {
+	oldKey := "0123456789abcdef0123456789abcdef"
+	kr := NewKeyring()
+	kr.Add("v1", oldKey)
+	kr.Add("v2", "fedcba9876543210fedcba9876543210")
+	if err := kr.SetActive("v2"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := AESEncrypt([]byte(oldKey), []byte("legacy"))
+	got, err := kr.Decrypt("", data)
+	if err != nil || string(got) != "legacy" {
+		t.Fatalf("Decrypt() = %q, %v; want legacy", got, err)
+	}
+
+	if err := kr.SetDefault("v2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kr.Decrypt("", data); err == nil {
+		t.Error("Decrypt() with the new default key succeeded, want error")
+	}
+	if err := kr.SetDefault("missing"); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("SetDefault(missing) error = %v, want ErrUnknownKeyID", err)
+	}
+}
+
+// TestClientWithEncryptionKeyring 测试客户端发送的信封携带 kid
+func TestClientWithEncryptionKeyring(t *testing.T) {
+	kr := NewKeyring()
+	kr.Add("2024-q1", "0123456789abcdef0123456789abcdef")
+
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- body
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "TestApp", WithEncryptionKeyring(kr))
+	defer client.Close()
+
+	if err := client.sendEvents(context.Background(), []*Event{{Name: "secret", Timestamp: time.Now().Unix()}}); err != nil {
+		t.Fatalf("sendEvents() error = %v", err)
+	}
+
+	body := <-received
+	var env EncryptedEnvelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		t.Fatalf("invalid envelope: %v", err)
+	}
+	if env.KeyID != "2024-q1" {
+		t.Errorf("envelope kid = %q, want 2024-q1", env.KeyID)
+	}
+
+	plaintext, err := DecryptEnvelope(kr, body)
+	if err != nil {
+		t.Fatalf("DecryptEnvelope() error = %v", err)
+	}
+	var payload struct {
+		Product string  `json:"product"`
+		Events  []Event `json:"events"`
+	}
+	json.Unmarshal(plaintext, &payload)
+	if payload.Product != "TestApp" || len(payload.Events) != 1 || payload.Events[0].Name != "secret" {
+		t.Errorf("unexpected payload: %s", plaintext)
+	}
+}
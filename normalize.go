@@ -0,0 +1,493 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// =============================================================================
+// 属性规范化：入队时把 Go 值转换为稳定的 JSON 表示
+// =============================================================================
+
+// 属性限制的默认值
+const (
+	DefaultMaxPropertyDepth = 8
+	DefaultMaxStringLength  = 4096
+	DefaultMaxPropertyKeys  = 128
+	DefaultMaxPropertyItems = 256
+)
+
+// PropertyLimits 事件属性的大小限制，零值字段使用默认值
+type PropertyLimits struct {
+	// MaxDepth map/数组的最大嵌套层数，超出的值被丢弃
+	MaxDepth int
+
+	// MaxStringLength 字符串的最大字节数，超出部分被截断（不会截断在字符中间）
+	MaxStringLength int
+
+	// MaxKeys 每个 map 的最大键数，超出时按键排序保留前 MaxKeys 个
+	MaxKeys int
+
+	// MaxItems 每个数组的最大元素数，超出部分被截断
+	MaxItems int
+}
+
+// WithPropertyLimits 设置事件属性的大小限制
+func WithPropertyLimits(limits PropertyLimits) ClientOption {
+	return func(c *Client) {
+		c.propertyLimits = limits.withDefaults()
+	}
+}
+
+// withDefaults 为零值字段填充默认值
+func (l PropertyLimits) withDefaults() PropertyLimits {
+	if l.MaxDepth <= 0 {
+		l.MaxDepth = DefaultMaxPropertyDepth
+	}
+	if l.MaxStringLength <= 0 {
+		l.MaxStringLength = DefaultMaxStringLength
+	}
+	if l.MaxKeys <= 0 {
+		l.MaxKeys = DefaultMaxPropertyKeys
+	}
+	if l.MaxItems <= 0 {
+		l.MaxItems = DefaultMaxPropertyItems
+	}
+	return l
+}
+
+// normalizeEvent 规范化事件属性，无法表示的值被丢弃并记录警告
+//
+// 转换规则：
+//   - time.Time 转为 RFC 3339 字符串，error 转为 Error()，fmt.Stringer 转为 String()
+//   - []byte 为合法 UTF-8 时转为字符串，否则转为 base64
+//   - 实现 json.Marshaler 的值与结构体按 encoding/json 编码后保存快照
+//   - 其他 map、切片、数组与指针递归转换为 map[string]interface{} 与 []interface{}
+//   - channel、函数、复数、NaN、±Inf 与循环引用被丢弃
+//
+// 属性已经合法时不复制；需要转换时生成新的 map，不修改调用方的数据。
+func (c *Client) normalizeEvent(event *Event) {
+	var warnings []string
+	limits := c.propertyLimits.withDefaults()
+	if len(event.Properties) > 0 && !isClean(limits, event.Properties, 1) {
+		n := normalizer{limits: limits, warnings: &warnings}
+		if out, changed := n.normalizeMap(&propPath{key: "properties"}, event.Properties, 1); changed {
+			event.Properties = out
+		}
+	}
+	if math.IsNaN(event.Value) || math.IsInf(event.Value, 0) {
+		warnings = append(warnings, fmt.Sprintf("value: dropped unsupported number %v", event.Value))
+		event.Value = 0
+	}
+	if len(warnings) == 0 {
+		return
+	}
+
+	if c.debug && c.logger != nil {
+		for _, w := range warnings {
+			c.logger.Printf("[Analytics] Event %q: %s", event.Name, w)
+		}
+	}
+	c.logAttrs(slog.LevelWarn, "analytics: event properties normalized",
+		slog.String("event", event.Name), slog.Any("warnings", warnings))
+}
+
+// isClean 快速检查属性是否已经合法，合法时跳过规范化，避免常见情况下的内存分配
+func isClean(limits PropertyLimits, v interface{}, depth int) bool {
+	switch x := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	case string:
+		return len(x) <= limits.MaxStringLength
+	case float64:
+		return !math.IsNaN(x) && !math.IsInf(x, 0)
+	case []string:
+		if len(x) > limits.MaxItems {
+			return false
+		}
+		for _, item := range x {
+			if len(item) > limits.MaxStringLength {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		if depth > limits.MaxDepth || len(x) > limits.MaxKeys {
+			return false
+		}
+		for _, item := range x {
+			if !isClean(limits, item, depth+1) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		if depth > limits.MaxDepth || len(x) > limits.MaxItems {
+			return false
+		}
+		for _, item := range x {
+			if !isClean(limits, item, depth+1) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// normalizer 一次规范化的状态
+type normalizer struct {
+	limits   PropertyLimits
+	warnings *[]string
+	visiting map[uintptr]bool // 当前路径上的 map/切片/指针，用于检测循环引用
+}
+
+// propPath 属性路径，只在需要记录警告时才拼接成字符串
+type propPath struct {
+	parent *propPath
+	key    string
+	index  int
+	elem   bool // 数组元素，使用 index
+}
+
+func (p *propPath) String() string {
+	var parts []string
+	for ; p != nil; p = p.parent {
+		if p.elem {
+			parts = append(parts, "["+strconv.Itoa(p.index)+"]")
+		} else {
+			parts = append(parts, "."+p.key)
+		}
+	}
+	var b strings.Builder
+	for i := len(parts) - 1; i >= 0; i-- {
+		b.WriteString(parts[i])
+	}
+	return strings.TrimPrefix(b.String(), ".")
+}
+
+// warn 记录被转换或丢弃的值
+func (n *normalizer) warn(path *propPath, format string, args ...interface{}) {
+	*n.warnings = append(*n.warnings, path.String()+": "+fmt.Sprintf(format, args...))
+}
+
+// normalizeMap 规范化 map，返回结果以及是否与输入不同
+func (n *normalizer) normalizeMap(path *propPath, m map[string]interface{}, depth int) (map[string]interface{}, bool) {
+	// 第一次需要修改时才复制
+	var out map[string]interface{}
+	if len(m) > n.limits.MaxKeys {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		n.warn(path, "dropped %d keys over the limit of %d", len(keys)-n.limits.MaxKeys, n.limits.MaxKeys)
+		out = make(map[string]interface{}, n.limits.MaxKeys)
+		for _, k := range keys[:n.limits.MaxKeys] {
+			out[k] = m[k]
+		}
+		m = out
+	}
+
+	for k, v := range m {
+		nv, keep, changed := n.normalize(&propPath{parent: path, key: k}, v, depth)
+		if !changed {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]interface{}, len(m))
+			for k2, v2 := range m {
+				out[k2] = v2
+			}
+		}
+		if keep {
+			out[k] = nv
+		} else {
+			delete(out, k)
+		}
+	}
+	if out == nil {
+		return m, false
+	}
+	return out, true
+}
+
+// normalizeSlice 规范化 []interface{}
+func (n *normalizer) normalizeSlice(path *propPath, s []interface{}, depth int) ([]interface{}, bool) {
+	truncated := false
+	if len(s) > n.limits.MaxItems {
+		n.warn(path, "truncated %d items over the limit of %d", len(s)-n.limits.MaxItems, n.limits.MaxItems)
+		s = s[:n.limits.MaxItems]
+		truncated = true
+	}
+
+	var out []interface{}
+	for i, item := range s {
+		v, keep, changed := n.normalize(&propPath{parent: path, index: i, elem: true}, item, depth)
+		if changed && out == nil {
+			out = make([]interface{}, i, len(s))
+			copy(out, s[:i])
+		}
+		if out != nil && keep {
+			out = append(out, v)
+		}
+	}
+	if out != nil {
+		return out, true
+	}
+	if truncated {
+		return append([]interface{}(nil), s...), true
+	}
+	return s, false
+}
+
+// normalize 规范化单个值，返回结果、是否保留以及是否与输入不同
+func (n *normalizer) normalize(path *propPath, v interface{}, depth int) (interface{}, bool, bool) {
+	switch x := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return v, true, false
+	case string:
+		if s, ok := n.truncate(path, x); ok {
+			return s, true, true
+		}
+		return v, true, false
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			n.warn(path, "dropped unsupported number %v", x)
+			return nil, false, true
+		}
+		return v, true, false
+	case float32:
+		if math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) {
+			n.warn(path, "dropped unsupported number %v", x)
+			return nil, false, true
+		}
+		return v, true, false
+	case json.Number:
+		if _, err := strconv.ParseFloat(string(x), 64); err != nil {
+			n.warn(path, "dropped invalid number %q", string(x))
+			return nil, false, true
+		}
+		return v, true, false
+	case time.Time:
+		return x.Format(time.RFC3339Nano), true, true
+	case []byte:
+		if x == nil {
+			return nil, true, true
+		}
+		if utf8.Valid(x) {
+			s, _ := n.truncate(path, string(x))
+			return s, true, true
+		}
+		return base64.StdEncoding.EncodeToString(x), true, true
+	case []string:
+		if len(x) <= n.limits.MaxItems {
+			clean := true
+			for _, item := range x {
+				clean = clean && len(item) <= n.limits.MaxStringLength
+			}
+			if clean {
+				return v, true, false
+			}
+		}
+	case map[string]interface{}:
+		if x == nil {
+			return v, true, false
+		}
+		if !n.enter(path, reflect.ValueOf(x).Pointer(), depth) {
+			return nil, false, true
+		}
+		defer n.leave(reflect.ValueOf(x).Pointer())
+		if out, changed := n.normalizeMap(path, x, depth+1); changed {
+			return out, true, true
+		}
+		return v, true, false
+	case []interface{}:
+		if x == nil {
+			return v, true, false
+		}
+		if !n.enter(path, reflect.ValueOf(x).Pointer(), depth) {
+			return nil, false, true
+		}
+		defer n.leave(reflect.ValueOf(x).Pointer())
+		if out, changed := n.normalizeSlice(path, x, depth+1); changed {
+			return out, true, true
+		}
+		return v, true, false
+	case json.Marshaler:
+		return n.snapshot(path, x, depth)
+	case error:
+		return n.call(path, func() string { return x.Error() })
+	case fmt.Stringer:
+		return n.call(path, func() string { return x.String() })
+	}
+	return n.normalizeReflect(path, reflect.ValueOf(v), depth)
+}
+
+// normalizeReflect 处理具名类型、类型化的 map/切片、指针与结构体
+func (n *normalizer) normalizeReflect(path *propPath, rv reflect.Value, depth int) (interface{}, bool, bool) {
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), true, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), true, true
+	case reflect.Float32, reflect.Float64:
+		v, keep, _ := n.normalize(path, rv.Float(), depth)
+		return v, keep, true
+	case reflect.String:
+		s, _ := n.truncate(path, rv.String())
+		return s, true, true
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil, true, true
+		}
+		if rv.Kind() == reflect.Pointer {
+			if !n.enter(path, rv.Pointer(), depth-1) {
+				return nil, false, true
+			}
+			defer n.leave(rv.Pointer())
+		}
+		v, keep, _ := n.normalize(path, rv.Elem().Interface(), depth)
+		return v, keep, true
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil, true, true
+		}
+		if !n.enter(path, rv.Pointer(), depth) {
+			return nil, false, true
+		}
+		defer n.leave(rv.Pointer())
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[mapKey(iter.Key())] = iter.Value().Interface()
+		}
+		out, _ := n.normalizeMap(path, m, depth+1)
+		return out, true, true
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice {
+			if rv.IsNil() {
+				return nil, true, true
+			}
+			if !n.enter(path, rv.Pointer(), depth) {
+				return nil, false, true
+			}
+			defer n.leave(rv.Pointer())
+		} else if depth >= n.limits.MaxDepth {
+			n.warn(path, "dropped value nested deeper than %d", n.limits.MaxDepth)
+			return nil, false, true
+		}
+		s := make([]interface{}, rv.Len())
+		for i := range s {
+			s[i] = rv.Index(i).Interface()
+		}
+		out, _ := n.normalizeSlice(path, s, depth+1)
+		return out, true, true
+	case reflect.Struct:
+		return n.snapshot(path, rv.Interface(), depth)
+	}
+	n.warn(path, "dropped unsupported value of type %s", rv.Type())
+	return nil, false, true
+}
+
+// snapshot 按 encoding/json 编码后解码，保存当前值的快照
+func (n *normalizer) snapshot(path *propPath, v interface{}, depth int) (out interface{}, keep bool, changed bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			n.warn(path, "dropped value of type %T: panic during encoding: %v", v, r)
+			out, keep, changed = nil, false, true
+		}
+	}()
+	data, err := json.Marshal(v)
+	if err != nil {
+		n.warn(path, "dropped value of type %T: %v", v, err)
+		return nil, false, true
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var decoded interface{}
+	if err := dec.Decode(&decoded); err != nil {
+		n.warn(path, "dropped value of type %T: %v", v, err)
+		return nil, false, true
+	}
+	out, keep, _ = n.normalize(path, decoded, depth)
+	return out, keep, true
+}
+
+// call 调用 Error()/String()，panic（例如 nil 指针接收者）时丢弃该值
+func (n *normalizer) call(path *propPath, fn func() string) (out interface{}, keep bool, changed bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			n.warn(path, "dropped value: panic during conversion: %v", r)
+			out, keep, changed = nil, false, true
+		}
+	}()
+	s, _ := n.truncate(path, fn())
+	return s, true, true
+}
+
+// enter 进入 map/切片/指针，超过最大深度或存在循环引用时返回 false
+func (n *normalizer) enter(path *propPath, ptr uintptr, depth int) bool {
+	if depth >= n.limits.MaxDepth {
+		n.warn(path, "dropped value nested deeper than %d", n.limits.MaxDepth)
+		return false
+	}
+	if n.visiting[ptr] {
+		n.warn(path, "dropped cyclic reference")
+		return false
+	}
+	if n.visiting == nil {
+		n.visiting = make(map[uintptr]bool)
+	}
+	n.visiting[ptr] = true
+	return true
+}
+
+// leave 离开 map/切片/指针
+func (n *normalizer) leave(ptr uintptr) {
+	delete(n.visiting, ptr)
+}
+
+// truncate 截断过长的字符串，返回结果以及是否被截断
+func (n *normalizer) truncate(path *propPath, s string) (string, bool) {
+	if len(s) <= n.limits.MaxStringLength {
+		return s, false
+	}
+	end := n.limits.MaxStringLength
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	n.warn(path, "truncated string of %d bytes to %d", len(s), end)
+	return s[:end], true
+}
+
+// mapKey 按 encoding/json 的规则把 map 键转换为字符串
+func mapKey(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	if tm, ok := k.Interface().(interface{ MarshalText() ([]byte, error) }); ok {
+		if text, err := tm.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10)
+	}
+	return fmt.Sprint(k.Interface())
+}
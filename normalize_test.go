@@ -0,0 +1,166 @@
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testLevel int
+
+func (l testLevel) String() string { return [...]string{"low", "high"}[l] }
+
+type testPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// normalizeProps 规范化属性，返回结果与警告
+func normalizeProps(limits PropertyLimits, props map[string]interface{}) (map[string]interface{}, []string) {
+	var warnings []string
+	n := normalizer{limits: limits.withDefaults(), warnings: &warnings}
+	out, _ := n.normalizeMap(&propPath{key: "properties"}, props, 1)
+	return out, warnings
+}
+
+// TestNormalizeConversions 测试常见 Go 类型转换为稳定的 JSON 表示
+func TestNormalizeConversions(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	n := 3
+	props := map[string]interface{}{
+		"time":   ts,
+		"error":  errors.New("boom"),
+		"bytes":  []byte("hello"),
+		"binary": []byte{0xff, 0x00},
+		"level":  testLevel(1),
+		"point":  testPoint{X: 1, Y: 2},
+		"ptr":    &n,
+		"nilptr": (*int)(nil),
+		"ints":   []int{1, 2},
+		"counts": map[string]int{"a": 1},
+		"byid":   map[int]string{7: "x"},
+		"raw":    json.RawMessage(`{"k":[1,2]}`),
+		"nested": map[string]interface{}{"when": ts},
+		"plain":  "ok",
+		"number": 1.5,
+	}
+
+	out, warnings := normalizeProps(PropertyLimits{}, props)
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v, want none", warnings)
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		t.Fatalf("json.Marshal(normalized) error = %v", err)
+	}
+	want := `{"binary":"/wA=","byid":{"7":"x"},"bytes":"hello","counts":{"a":1},` +
+		`"error":"boom","ints":[1,2],"level":"high","nested":{"when":"2024-05-01T12:30:00Z"},` +
+		`"nilptr":null,"number":1.5,"plain":"ok","point":{"x":1,"y":2},"ptr":3,` +
+		`"raw":{"k":[1,2]},"time":"2024-05-01T12:30:00Z"}`
+	if string(data) != want {
+		t.Errorf("normalized =\n%s\nwant\n%s", data, want)
+	}
+	if _, ok := props["time"].(time.Time); !ok {
+		t.Error("caller's properties were modified")
+	}
+}
+
+// TestNormalizeDropsUnsupported 测试无法表示的值被丢弃并记录警告
+func TestNormalizeDropsUnsupported(t *testing.T) {
+	cyclic := map[string]interface{}{}
+	cyclic["self"] = cyclic
+	list := []interface{}{1}
+	list = append(list, list)
+	list[1] = list
+
+	props := map[string]interface{}{
+		"chan":    make(chan int),
+		"func":    func() {},
+		"nan":     math.NaN(),
+		"inf":     math.Inf(-1),
+		"complex": complex(1, 2),
+		"cyclic":  cyclic,
+		"list":    list,
+		"keep":    "ok",
+	}
+	out, warnings := normalizeProps(PropertyLimits{}, props)
+
+	for _, key := range []string{"chan", "func", "nan", "inf", "complex"} {
+		if _, ok := out[key]; ok {
+			t.Errorf("%s was not dropped", key)
+		}
+	}
+	if out["keep"] != "ok" {
+		t.Errorf("keep = %v, want ok", out["keep"])
+	}
+	if _, err := json.Marshal(out); err != nil {
+		t.Errorf("json.Marshal(normalized) error = %v", err)
+	}
+	joined := strings.Join(warnings, "\n")
+	for _, want := range []string{"properties.chan", "properties.cyclic.self: dropped cyclic reference", "properties.list[1]"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("warnings %q do not mention %q", warnings, want)
+		}
+	}
+}
+
+// TestNormalizeLimits 测试深度、字符串长度、键数与元素数限制
+func TestNormalizeLimits(t *testing.T) {
+	limits := PropertyLimits{MaxDepth: 2, MaxStringLength: 5, MaxKeys: 3, MaxItems: 2}
+	props := map[string]interface{}{
+		"a":    "héllo world", // 在字符中间截断时回退到字符边界
+		"b":    []interface{}{1, 2, 3},
+		"c":    map[string]interface{}{"deep": map[string]interface{}{"x": 1}},
+		"d":    1,
+		"zzzz": 2,
+	}
+	out, warnings := normalizeProps(limits, props)
+
+	if len(out) != 3 {
+		t.Errorf("kept %d keys, want 3: %v", len(out), out)
+	}
+	if out["a"] != "héll" {
+		t.Errorf("a = %q, want %q", out["a"], "héll")
+	}
+	if b := out["b"].([]interface{}); len(b) != 2 {
+		t.Errorf("b = %v, want 2 items", b)
+	}
+	if c := out["c"].(map[string]interface{}); len(c) != 0 {
+		t.Errorf("c = %v, want nested map dropped", c)
+	}
+	if len(warnings) != 4 {
+		t.Errorf("warnings = %q, want 4", warnings)
+	}
+}
+
+// TestNormalizeUnchanged 测试合法的属性不被复制
+func TestNormalizeUnchanged(t *testing.T) {
+	props := map[string]interface{}{"a": 1, "b": []string{"x"}, "c": map[string]interface{}{"d": []interface{}{true}}}
+	var warnings []string
+	n := normalizer{limits: PropertyLimits{}.withDefaults(), warnings: &warnings}
+	if _, changed := n.normalizeMap(&propPath{key: "properties"}, props, 1); changed {
+		t.Error("valid properties were reported as changed")
+	}
+}
+
+// TestTrackNormalizesProperties 测试无法编码的值不会导致同批次的事件丢失
+func TestTrackNormalizesProperties(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp", WithFlushInterval(time.Hour))
+	client.Track("good", map[string]interface{}{"n": 1})
+	client.Track("bad", map[string]interface{}{"ch": make(chan int), "nan": math.NaN(), "at": time.Unix(0, 0).UTC()})
+	client.Close()
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("sent %d events, want 2", len(events))
+	}
+	props := events[1].Properties
+	if len(props) != 1 || props["at"] != "1970-01-01T00:00:00Z" {
+		t.Errorf("bad event properties = %v", props)
+	}
+}
@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// =============================================================================
+// 发送观察者
+// =============================================================================
+
+// SendInfo 描述一次批量发送请求
+type SendInfo struct {
+	URL       string // 请求地址
+	BatchSize int    // 批次中的事件数
+	Bytes     int    // 请求体字节数
+}
+
+// SendResult 描述一次批量发送的结果
+type SendResult struct {
+	StatusCode int           // HTTP 状态码，未收到响应时为 0
+	Duration   time.Duration // 请求耗时
+	Err        error         // 发送错误，成功时为 nil
+}
+
+// SendObserver 观察每个批次的 HTTP 发送
+//
+// StartSend 在请求发出前调用，返回的 context 用于该次请求（可用于注入追踪信息），
+// 返回的函数在请求结束后以结果调用。可用于链路追踪、指标与日志。
+type SendObserver interface {
+	StartSend(ctx context.Context, info SendInfo) (context.Context, func(SendResult))
+}
+
+// WithSendObserver 注册发送观察者
+func WithSendObserver(observers ...SendObserver) ClientOption {
+	return func(c *Client) {
+		c.observers = append(c.observers, observers...)
+	}
+}
+
+// startSend 通知所有观察者开始发送，返回结束回调
+func (c *Client) startSend(ctx context.Context, info SendInfo) (context.Context, func(statusCode int, err error)) {
+	if len(c.observers) == 0 {
+		return ctx, func(int, error) {}
+	}
+
+	finishers := make([]func(SendResult), 0, len(c.observers))
+	for _, o := range c.observers {
+		var finish func(SendResult)
+		ctx, finish = o.StartSend(ctx, info)
+		finishers = append(finishers, finish)
+	}
+
+	start := time.Now()
+	return ctx, func(statusCode int, err error) {
+		result := SendResult{
+			StatusCode: statusCode,
+			Duration:   time.Since(start),
+			Err:        err,
+		}
+		for i := len(finishers) - 1; i >= 0; i-- {
+			if finishers[i] != nil {
+				finishers[i](result)
+			}
+		}
+	}
+}
+
+// statusCodeOf 从发送错误中提取 HTTP 状态码
+func statusCodeOf(err error) int {
+	var netErr *NetworkError
+	if errors.As(err, &netErr) {
+		return netErr.StatusCode
+	}
+	return 0
+}
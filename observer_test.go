@@ -0,0 +1,49 @@
+package analytics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// recordingObserver 记录发送信息与结果
+type recordingObserver struct {
+	infos   []SendInfo
+	results []SendResult
+}
+
+func (o *recordingObserver) StartSend(ctx context.Context, info SendInfo) (context.Context, func(SendResult)) {
+	o.infos = append(o.infos, info)
+	return ctx, func(r SendResult) { o.results = append(o.results, r) }
+}
+
+// TestSendObserver 测试观察者收到批次信息与发送结果
+func TestSendObserver(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	obs := &recordingObserver{}
+	client := NewClient(server.URL, "TestApp", WithSendObserver(obs))
+	defer client.Close()
+
+	client.sendEvents(context.Background(), []*Event{{Name: "a"}, {Name: "b"}})
+	status = http.StatusBadRequest
+	client.sendEvents(context.Background(), []*Event{{Name: "c"}})
+
+	if len(obs.infos) != 2 || len(obs.results) != 2 {
+		t.Fatalf("observer called %d/%d times, want 2/2", len(obs.infos), len(obs.results))
+	}
+	if obs.infos[0].BatchSize != 2 || obs.infos[0].Bytes == 0 {
+		t.Errorf("infos[0] = %+v", obs.infos[0])
+	}
+	if obs.results[0].StatusCode != http.StatusOK || obs.results[0].Err != nil {
+		t.Errorf("results[0] = %+v", obs.results[0])
+	}
+	if obs.results[1].StatusCode != http.StatusBadRequest || obs.results[1].Err == nil {
+		t.Errorf("results[1] = %+v", obs.results[1])
+	}
+}
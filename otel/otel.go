@@ -0,0 +1,138 @@
+// Package analyticsotel 为分析客户端提供 OpenTelemetry 集成
+//
+// 将事件与链路追踪关联：
+//
+//	client := analytics.NewClient(url, "MyApp", analyticsotel.WithTracing())
+//
+//	// 在带有 span 的 context 中发送事件，事件会携带 trace_id 与 span_id
+//	client.TrackContext(ctx, "checkout", props)
+//
+// 本包是独立的 Go module，核心 SDK 不依赖 OpenTelemetry。
+package analyticsotel
+
+import (
+	"context"
+	"net/http"
+
+	analytics "github.com/difyz9/go-analysis-client"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 本包的 instrumentation scope 名称
+const instrumentationName = "github.com/difyz9/go-analysis-client/otel"
+
+// 事件属性名
+const (
+	TraceIDProperty = "trace_id"
+	SpanIDProperty  = "span_id"
+)
+
+// 批次 span 属性
+var (
+	batchSizeKey  = attribute.Key("analytics.batch.size")
+	batchBytesKey = attribute.Key("analytics.batch.bytes")
+)
+
+// config 集成配置
+type config struct {
+	tracerProvider trace.TracerProvider
+	sendSpans      bool
+}
+
+// Option 集成配置选项
+type Option func(*config)
+
+// WithTracerProvider 设置用于创建发送 span 的 TracerProvider，默认使用全局 TracerProvider
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithSendSpans 设置是否为每个批次的 HTTP 发送创建 client span，默认启用
+func WithSendSpans(enabled bool) Option {
+	return func(c *config) {
+		c.sendSpans = enabled
+	}
+}
+
+// WithTracing 返回启用追踪关联的客户端选项
+//
+// 注册 TraceExtractor，并（默认）注册 SendObserver 为每次发送创建 span。
+func WithTracing(opts ...Option) analytics.ClientOption {
+	cfg := &config{sendSpans: true}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(c *analytics.Client) {
+		analytics.WithContextExtractor(TraceExtractor())(c)
+		if cfg.sendSpans {
+			analytics.WithSendObserver(newSendObserver(cfg))(c)
+		}
+	}
+}
+
+// TraceExtractor 返回从 context 中提取 trace_id 与 span_id 的提取器
+func TraceExtractor() analytics.ContextExtractor {
+	return func(ctx context.Context) map[string]interface{} {
+		sc := trace.SpanContextFromContext(ctx)
+		if !sc.IsValid() {
+			return nil
+		}
+		return map[string]interface{}{
+			TraceIDProperty: sc.TraceID().String(),
+			SpanIDProperty:  sc.SpanID().String(),
+		}
+	}
+}
+
+// NewSendObserver 创建为每个批次发送创建 client span 的观察者
+func NewSendObserver(opts ...Option) analytics.SendObserver {
+	cfg := &config{sendSpans: true}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return newSendObserver(cfg)
+}
+
+func newSendObserver(cfg *config) *sendObserver {
+	tp := cfg.tracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &sendObserver{tracer: tp.Tracer(instrumentationName)}
+}
+
+// sendObserver 实现 analytics.SendObserver
+type sendObserver struct {
+	tracer trace.Tracer
+}
+
+// StartSend 实现 analytics.SendObserver
+func (o *sendObserver) StartSend(ctx context.Context, info analytics.SendInfo) (context.Context, func(analytics.SendResult)) {
+	ctx, span := o.tracer.Start(ctx, "analytics.send_events",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(http.MethodPost),
+			semconv.URLFull(info.URL),
+			batchSizeKey.Int(info.BatchSize),
+			batchBytesKey.Int(info.Bytes),
+		),
+	)
+
+	return ctx, func(result analytics.SendResult) {
+		if result.StatusCode > 0 {
+			span.SetAttributes(semconv.HTTPResponseStatusCode(result.StatusCode))
+		}
+		if result.Err != nil {
+			span.RecordError(result.Err)
+			span.SetStatus(codes.Error, result.Err.Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,101 @@
+package analyticsotel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	analytics "github.com/difyz9/go-analysis-client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestTraceCorrelation 测试事件携带 trace_id/span_id，并为发送创建 span
+func TestTraceCorrelation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	received := make(chan []analytics.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []analytics.Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload.Events
+	}))
+	defer server.Close()
+
+	client := analytics.NewClient(server.URL, "TestApp", WithTracing(WithTracerProvider(tp)))
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "handler")
+	client.TrackContext(ctx, "traced", nil)
+	span.End()
+	client.Close()
+
+	events := <-received
+	if len(events) != 1 {
+		t.Fatalf("received %d events, want 1", len(events))
+	}
+	sc := span.SpanContext()
+	if got := events[0].Properties[TraceIDProperty]; got != sc.TraceID().String() {
+		t.Errorf("trace_id = %v, want %s", got, sc.TraceID())
+	}
+	if got := events[0].Properties[SpanIDProperty]; got != sc.SpanID().String() {
+		t.Errorf("span_id = %v, want %s", got, sc.SpanID())
+	}
+
+	var sendSpan sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.Name() == "analytics.send_events" {
+			sendSpan = s
+		}
+	}
+	if sendSpan == nil {
+		t.Fatal("send span not recorded")
+	}
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range sendSpan.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs[batchSizeKey].AsInt64() != 1 {
+		t.Errorf("batch size attribute = %v, want 1", attrs[batchSizeKey])
+	}
+	if attrs["http.response.status_code"].AsInt64() != http.StatusOK {
+		t.Errorf("status code attribute = %v, want 200", attrs["http.response.status_code"])
+	}
+}
+
+// TestSendSpanError 测试发送失败时 span 状态为 Error
+func TestSendSpanError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := analytics.NewClient(server.URL, "TestApp",
+		analytics.WithSendObserver(NewSendObserver(WithTracerProvider(tp))))
+	client.Track("fails", nil)
+	client.Close()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("span status = %v, want Error", spans[0].Status().Code)
+	}
+}
+
+// TestTraceExtractorWithoutSpan 测试没有 span 时不添加属性
+func TestTraceExtractorWithoutSpan(t *testing.T) {
+	if props := TraceExtractor()(context.Background()); props != nil {
+		t.Errorf("TraceExtractor() = %v, want nil", props)
+	}
+}
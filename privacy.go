@@ -0,0 +1,236 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// =============================================================================
+// GDPR 数据主体请求：删除与导出
+// =============================================================================
+
+// 数据主体请求的服务端路径
+const (
+	PrivacyDeletePath = "/api/privacy/delete"
+	PrivacyExportPath = "/api/privacy/export"
+)
+
+// PrivacyRequest 删除或导出请求的请求体
+//
+// Sign 与安装上报相同：SHA256(product#device_id#timestamp)。该值不含密钥，
+// 不能证明请求来自设备本身，因此数据主体请求还必须带上 WithSigningKey 的 HMAC 签名头。
+type PrivacyRequest struct {
+	Product   string `json:"product"`
+	DeviceID  string `json:"device_id"`
+	UserID    string `json:"user_id,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+	Sign      string `json:"sign"`
+}
+
+// identityState 持久化的身份信息
+type identityState struct {
+	DeviceID  string    `json:"device_id"`
+	RotatedAt time.Time `json:"rotated_at"`
+}
+
+// WithIdentityFile 设置设备ID的持久化文件
+//
+// 默认设备ID由主机信息生成，RequestDeletion 轮换后的新ID需要保存在该文件中，
+// 才能在重启后继续使用而不重新关联到主机或已删除的身份。
+// 文件存在时优先于 WithDeviceID：删除请求之后，显式指定的旧ID同样不再使用；
+// 需要换回显式ID时删除该文件。
+func WithIdentityFile(path string) ClientOption {
+	return func(c *Client) {
+		c.identityFile = path
+	}
+}
+
+// RequestDeletion 请求服务端删除当前设备与用户的所有数据
+//
+// 需要配置 WithSigningKey，否则返回 ErrSigningKeyRequired；需要配置 WithIdentityFile，
+// 否则返回 ErrIdentityFileRequired，因为无法保存的新身份会在重启后变回已删除的设备ID。
+//
+// 调用时立即丢弃尚未发送的事件，并在发送请求前轮换设备ID与会话ID、清空用户ID，
+// 请求期间记录的事件只会以新身份发送，无法与删除前的数据关联。服务端确认后
+// 持久化新的身份信息；请求失败时恢复原来的身份，可以重试。
+// 授权状态（WithConsentFile）会被保留。
+func (c *Client) RequestDeletion(ctx context.Context) error {
+	if err := c.requireSigningKey("RequestDeletion"); err != nil {
+		return err
+	}
+	if c.identityFile == "" {
+		return newClientError("RequestDeletion", ErrIdentityFileRequired)
+	}
+
+	// 先轮换身份并使已入队（包括正在组批）的事件失效，避免删除后又被发送
+	previous := c.rotateIdentity()
+	c.purgeQueue()
+
+	resp, err := c.sendPrivacyRequest(ctx, PrivacyDeletePath, previous)
+	if err != nil {
+		c.restoreIdentity(previous)
+		return err
+	}
+	resp.Body.Close()
+
+	if err := c.persistIdentity(); err != nil {
+		return err
+	}
+	if c.debug && c.logger != nil {
+		c.logger.Printf("[Analytics] Data deletion confirmed, device ID rotated")
+	}
+	c.logAttrs(slog.LevelInfo, "analytics: data deletion confirmed")
+	return nil
+}
+
+// RequestExport 请求服务端导出当前设备与用户的数据，返回服务端响应体
+//
+// 需要配置 WithSigningKey，否则返回 ErrSigningKeyRequired。
+func (c *Client) RequestExport(ctx context.Context) ([]byte, error) {
+	if err := c.requireSigningKey("RequestExport"); err != nil {
+		return nil, err
+	}
+	resp, err := c.sendPrivacyRequest(ctx, PrivacyExportPath, c.currentIdentity())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newNetworkError("POST", c.ActiveEndpoint()+PrivacyExportPath, resp.StatusCode,
+			fmt.Errorf("%w: %v", ErrNetworkFailure, err), true)
+	}
+	return data, nil
+}
+
+// requireSigningKey 数据主体请求必须使用 HMAC 签名，否则知道设备ID的任何人都能导出或删除数据
+func (c *Client) requireSigningKey(op string) error {
+	if len(c.signingKey) == 0 {
+		return newClientError(op, ErrSigningKeyRequired)
+	}
+	return nil
+}
+
+// sendPrivacyRequest 为指定身份发送数据主体请求
+func (c *Client) sendPrivacyRequest(ctx context.Context, path string, id identity) (*http.Response, error) {
+	timestamp := time.Now().Unix()
+	req := &PrivacyRequest{
+		Product:   c.productName,
+		DeviceID:  id.deviceID,
+		UserID:    id.userID,
+		Timestamp: timestamp,
+		Sign:      c.generateInstallSign(c.productName, id.deviceID, timestamp),
+	}
+	data, err := c.marshalJSON(req)
+	if err != nil {
+		return nil, newClientError("sendPrivacyRequest", fmt.Errorf("%w: %v", ErrMarshalFailed, err))
+	}
+	return c.post(ctx, path, "application/json", data, nil, 0)
+}
+
+// identity 客户端身份的快照
+type identity struct {
+	deviceID       string
+	deviceIDSet    bool
+	userID         string
+	sessionID      string
+	sessionStarted time.Time
+}
+
+// currentIdentity 返回当前身份
+func (c *Client) currentIdentity() identity {
+	c.identityMu.RLock()
+	defer c.identityMu.RUnlock()
+	return identity{c.deviceID, c.deviceIDSet, c.userID, c.sessionID, c.sessionStarted}
+}
+
+// rotateIdentity 生成新的设备ID与会话并清空用户ID，返回原来的身份
+//
+// 在同一把锁内使已入队的事件失效，sendEvents 在该锁内读取身份并复核事件代数，
+// 删除前入队的事件不会以新身份发送。
+func (c *Client) rotateIdentity() identity {
+	c.identityMu.Lock()
+	defer c.identityMu.Unlock()
+
+	previous := identity{c.deviceID, c.deviceIDSet, c.userID, c.sessionID, c.sessionStarted}
+	c.generation.Add(1)
+	c.deviceID = uuid.New().String()
+	c.deviceIDSet = false
+	c.userID = ""
+	c.sessionID = uuid.New().String()
+	c.sessionStarted = time.Now()
+	return previous
+}
+
+// restoreIdentity 删除请求失败时恢复原来的身份
+func (c *Client) restoreIdentity(id identity) {
+	c.identityMu.Lock()
+	defer c.identityMu.Unlock()
+
+	c.deviceID = id.deviceID
+	c.deviceIDSet = id.deviceIDSet
+	c.userID = id.userID
+	c.sessionID = id.sessionID
+	c.sessionStarted = id.sessionStarted
+}
+
+// persistIdentity 删除本地身份信息并保存轮换后的设备ID
+func (c *Client) persistIdentity() error {
+	c.identityMu.Lock()
+	defer c.identityMu.Unlock()
+
+	if c.identityFile != "" {
+		if err := os.Remove(c.identityFile); err != nil && !os.IsNotExist(err) {
+			return newClientError("persistIdentity", err)
+		}
+	}
+	return c.saveIdentity()
+}
+
+// loadIdentity 从持久化文件恢复设备ID，文件中轮换后的ID优先于 WithDeviceID
+func (c *Client) loadIdentity() {
+	if c.identityFile == "" {
+		return
+	}
+	data, err := os.ReadFile(c.identityFile)
+	if err != nil {
+		return
+	}
+	var state identityState
+	if err := json.Unmarshal(data, &state); err != nil || state.DeviceID == "" {
+		return
+	}
+	c.deviceID = state.DeviceID
+	c.deviceIDSet = false
+}
+
+// saveIdentity 持久化设备ID（调用方需持有 identityMu 写锁）
+func (c *Client) saveIdentity() error {
+	if c.identityFile == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(identityState{
+		DeviceID:  c.deviceID,
+		RotatedAt: time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return newClientError("saveIdentity", fmt.Errorf("%w: %v", ErrMarshalFailed, err))
+	}
+	if err := os.MkdirAll(filepath.Dir(c.identityFile), 0o755); err != nil {
+		return newClientError("saveIdentity", err)
+	}
+	if err := os.WriteFile(c.identityFile, data, 0o600); err != nil {
+		return newClientError("saveIdentity", err)
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// privacyServer 记录数据主体请求与事件的测试服务器
+type privacyServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests map[string]PrivacyRequest
+	signed   map[string]bool
+	events   int
+	devices  []string // 事件批次的设备ID
+	status   int
+
+	// hold 非 nil 时删除请求等待该通道关闭后才响应
+	hold     chan struct{}
+	received chan struct{}
+}
+
+func newPrivacyServer(t *testing.T) *privacyServer {
+	t.Helper()
+	ps := &privacyServer{requests: map[string]PrivacyRequest{}, signed: map[string]bool{}, status: http.StatusOK}
+	ps.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == PrivacyDeletePath && ps.hold != nil {
+			close(ps.received)
+			<-ps.hold
+		}
+		ps.mu.Lock()
+		defer ps.mu.Unlock()
+		if r.URL.Path == "/api/events/batch" {
+			var payload struct {
+				DeviceID string  `json:"device_id"`
+				Events   []Event `json:"events"`
+			}
+			json.NewDecoder(r.Body).Decode(&payload)
+			ps.events += len(payload.Events)
+			ps.devices = append(ps.devices, payload.DeviceID)
+			return
+		}
+		var req PrivacyRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		ps.requests[r.URL.Path] = req
+		ps.signed[r.URL.Path] = r.Header.Get(HeaderSignature) != ""
+		w.WriteHeader(ps.status)
+		if r.URL.Path == PrivacyExportPath {
+			w.Write([]byte(`{"events":[]}`))
+		}
+	}))
+	t.Cleanup(ps.Close)
+	return ps
+}
+
+// TestRequestDeletion 测试删除请求、丢弃队列与设备ID轮换
+func TestRequestDeletion(t *testing.T) {
+	server := newPrivacyServer(t)
+	path := filepath.Join(t.TempDir(), "identity.json")
+	client := NewClient(server.URL, "TestApp",
+		WithIdentityFile(path),
+		WithUserID("user-1"),
+		WithSigningKey("secret"),
+		WithFlushInterval(time.Hour),
+	)
+	defer client.Close()
+
+	oldDevice, oldSession := client.GetDeviceID(), client.GetSessionID()
+	client.Track("before_deletion", nil)
+
+	if err := client.RequestDeletion(context.Background()); err != nil {
+		t.Fatalf("RequestDeletion: %v", err)
+	}
+
+	server.mu.Lock()
+	req := server.requests[PrivacyDeletePath]
+	signed := server.signed[PrivacyDeletePath]
+	server.mu.Unlock()
+	if req.DeviceID != oldDevice || req.UserID != "user-1" || req.Sign == "" {
+		t.Errorf("deletion request = %+v, want device %s and user user-1", req, oldDevice)
+	}
+	if !signed {
+		t.Error("deletion request was not signed")
+	}
+
+	if client.GetDeviceID() == oldDevice || client.GetSessionID() == oldSession {
+		t.Error("device or session ID was not rotated")
+	}
+
+	client.Track("after_deletion", nil)
+	client.Close()
+	server.mu.Lock()
+	if server.events != 1 {
+		t.Errorf("server received %d events, want only the one tracked after deletion", server.events)
+	}
+	server.mu.Unlock()
+
+	// 重启后沿用轮换后的设备ID
+	restarted := NewClient(server.URL, "TestApp", WithIdentityFile(path))
+	defer restarted.Close()
+	if restarted.GetDeviceID() != client.GetDeviceID() {
+		t.Errorf("restored device ID = %s, want %s", restarted.GetDeviceID(), client.GetDeviceID())
+	}
+}
+
+// TestRequestDeletionFailureKeepsIdentity 测试请求失败时身份不变
+func TestRequestDeletionFailureKeepsIdentity(t *testing.T) {
+	server := newPrivacyServer(t)
+	server.status = http.StatusForbidden
+	path := filepath.Join(t.TempDir(), "identity.json")
+	client := NewClient(server.URL, "TestApp", WithUserID("user-1"), WithSigningKey("secret"), WithIdentityFile(path))
+	defer client.Close()
+
+	deviceID, sessionID := client.GetDeviceID(), client.GetSessionID()
+	if err := client.RequestDeletion(context.Background()); err == nil {
+		t.Fatal("RequestDeletion succeeded on 403")
+	}
+	if client.GetDeviceID() != deviceID || client.GetSessionID() != sessionID || client.currentIdentity().userID != "user-1" {
+		t.Error("identity was not restored after failed deletion request")
+	}
+}
+
+// TestRequestDeletionRotatesBeforeRequest 测试删除请求期间记录的事件以新身份发送
+func TestRequestDeletionRotatesBeforeRequest(t *testing.T) {
+	server := newPrivacyServer(t)
+	server.hold = make(chan struct{})
+	server.received = make(chan struct{})
+	client := NewClient(server.URL, "TestApp", WithSigningKey("secret"), WithBatchSize(1),
+		WithIdentityFile(filepath.Join(t.TempDir(), "identity.json")))
+	defer client.Close()
+
+	oldDevice := client.GetDeviceID()
+	done := make(chan error, 1)
+	go func() { done <- client.RequestDeletion(context.Background()) }()
+
+	<-server.received
+	client.Track("during_deletion", nil)
+	waitFor(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return server.events == 1
+	})
+	close(server.hold)
+	if err := <-done; err != nil {
+		t.Fatalf("RequestDeletion: %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.devices[0] == oldDevice || server.devices[0] != client.GetDeviceID() {
+		t.Errorf("event sent with device %s during deletion, want rotated %s", server.devices[0], client.GetDeviceID())
+	}
+}
+
+// TestPrivacyRequestsRequireSigningKey 测试未配置签名密钥时拒绝数据主体请求
+func TestPrivacyRequestsRequireSigningKey(t *testing.T) {
+	server := newPrivacyServer(t)
+	client := NewClient(server.URL, "TestApp")
+	defer client.Close()
+
+	deviceID := client.GetDeviceID()
+	if err := client.RequestDeletion(context.Background()); !errors.Is(err, ErrSigningKeyRequired) {
+		t.Errorf("RequestDeletion() error = %v, want ErrSigningKeyRequired", err)
+	}
+	if _, err := client.RequestExport(context.Background()); !errors.Is(err, ErrSigningKeyRequired) {
+		t.Errorf("RequestExport() error = %v, want ErrSigningKeyRequired", err)
+	}
+	if client.GetDeviceID() != deviceID {
+		t.Error("device ID rotated without a deletion request")
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.requests) != 0 {
+		t.Errorf("server received %d unsigned privacy requests", len(server.requests))
+	}
+}
+
+// TestRequestExport 测试导出请求返回服务端数据
+func TestRequestExport(t *testing.T) {
+	server := newPrivacyServer(t)
+	client := NewClient(server.URL, "TestApp", WithDeviceID("device-1"), WithSigningKey("secret"))
+	defer client.Close()
+
+	data, err := client.RequestExport(context.Background())
+	if err != nil {
+		t.Fatalf("RequestExport: %v", err)
+	}
+	if string(data) != `{"events":[]}` {
+		t.Errorf("export data = %s", data)
+	}
+	if got := server.requests[PrivacyExportPath].DeviceID; got != "device-1" {
+		t.Errorf("export device ID = %q, want device-1", got)
+	}
+	if client.GetDeviceID() != "device-1" {
+		t.Error("export must not rotate the device ID")
+	}
+}
+
+// TestRequestDeletionConcurrentSession 测试轮换会话与读取会话开始时间并发（配合 -race）
+func TestRequestDeletionConcurrentSession(t *testing.T) {
+	server := newPrivacyServer(t)
+	client := NewClient(server.URL, "TestApp", WithSigningKey("secret"), WithFlushInterval(time.Hour),
+		WithIdentityFile(filepath.Join(t.TempDir(), "identity.json")))
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			client.TrackAppExit(nil)
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		if err := client.RequestDeletion(context.Background()); err != nil {
+			t.Fatalf("RequestDeletion: %v", err)
+		}
+	}
+	wg.Wait()
+}
+
+// TestRequestDeletionExplicitDeviceIDRestart 测试显式指定的设备ID在删除后重启也不会恢复
+func TestRequestDeletionExplicitDeviceIDRestart(t *testing.T) {
+	server := newPrivacyServer(t)
+	path := filepath.Join(t.TempDir(), "identity.json")
+	client := NewClient(server.URL, "TestApp", WithDeviceID("device-1"), WithSigningKey("secret"), WithIdentityFile(path))
+	if err := client.RequestDeletion(context.Background()); err != nil {
+		t.Fatalf("RequestDeletion: %v", err)
+	}
+	rotated := client.GetDeviceID()
+	client.Close()
+
+	restarted := NewClient(server.URL, "TestApp", WithDeviceID("device-1"), WithIdentityFile(path))
+	defer restarted.Close()
+	if got := restarted.GetDeviceID(); got == "device-1" || got != rotated {
+		t.Errorf("device ID after restart = %s, want rotated %s", got, rotated)
+	}
+}
+
+// TestRequestDeletionRequiresIdentityFile 测试无法保存新身份时拒绝删除请求
+func TestRequestDeletionRequiresIdentityFile(t *testing.T) {
+	server := newPrivacyServer(t)
+	client := NewClient(server.URL, "TestApp", WithSigningKey("secret"))
+	defer client.Close()
+
+	deviceID := client.GetDeviceID()
+	if err := client.RequestDeletion(context.Background()); !errors.Is(err, ErrIdentityFileRequired) {
+		t.Errorf("RequestDeletion() error = %v, want ErrIdentityFileRequired", err)
+	}
+	if client.GetDeviceID() != deviceID {
+		t.Error("device ID rotated without a deletion request")
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.requests) != 0 {
+		t.Error("deletion request sent without an identity file")
+	}
+}
@@ -0,0 +1,201 @@
+package analytics
+
+import (
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 客户端限流与事件配额
+// =============================================================================
+
+// RateLimitedEventName 定期汇总被限流事件数量的事件名称
+const RateLimitedEventName = "events_rate_limited"
+
+// 汇总事件中按名称记录的最大事件数，超出部分计入 "_other"
+const maxRateLimitedNames = 100
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	rate  float64 // 每秒补充的令牌数
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// allow 尝试取出一个令牌
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.last.IsZero() {
+		elapsed := now.Sub(b.last).Seconds()
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimiter 全局令牌桶与按事件名称的配额，并统计被抑制的事件
+type rateLimiter struct {
+	bucket         *tokenBucket
+	quotas         map[string]*fingerprintLimiter
+	defaultQuota   *fingerprintLimiter
+	reportInterval time.Duration
+
+	mu            sync.Mutex
+	suppressed    map[string]int
+	bufferDropped int
+}
+
+// WithRateLimit 设置整个客户端的令牌桶限流：每秒 perSecond 个事件，允许突发 burst 个
+//
+// 被限流的事件不会进入发送队列，而是定期汇总为一个 RateLimitedEventName 事件。
+func WithRateLimit(perSecond float64, burst int) ClientOption {
+	return func(c *Client) {
+		c.ensureRateLimiter().bucket = newTokenBucket(perSecond, burst)
+	}
+}
+
+// WithEventQuota 限制指定事件在每个 interval 内最多发送 limit 次
+func WithEventQuota(eventName string, limit int, interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.ensureRateLimiter().quotas[eventName] = newFingerprintLimiter(limit, interval)
+	}
+}
+
+// WithDefaultEventQuota 限制未单独配置配额的每个事件名称在每个 interval 内最多发送 limit 次
+func WithDefaultEventQuota(limit int, interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.ensureRateLimiter().defaultQuota = newFingerprintLimiter(limit, interval)
+	}
+}
+
+// WithRateLimitReportInterval 设置限流汇总事件的发送间隔，默认 1 分钟
+func WithRateLimitReportInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.ensureRateLimiter().reportInterval = interval
+	}
+}
+
+// ensureRateLimiter 返回限流器，不存在时创建
+func (c *Client) ensureRateLimiter() *rateLimiter {
+	if c.limiter == nil {
+		c.limiter = &rateLimiter{
+			quotas:         make(map[string]*fingerprintLimiter),
+			reportInterval: time.Minute,
+			suppressed:     make(map[string]int),
+		}
+	}
+	return c.limiter
+}
+
+// allow 先检查事件配额，再检查全局令牌桶，被拒绝时记入统计
+func (l *rateLimiter) allow(name string, now time.Time) bool {
+	quota, ok := l.quotas[name]
+	if !ok {
+		quota = l.defaultQuota
+	}
+	if allowed, _, _ := quota.allow(name, now); !allowed {
+		l.record(name)
+		return false
+	}
+	if l.bucket != nil && !l.bucket.allow(now) {
+		l.record(name)
+		return false
+	}
+	return true
+}
+
+// record 记录一次被抑制的事件
+func (l *rateLimiter) record(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.suppressed[name]; !ok && len(l.suppressed) >= maxRateLimitedNames {
+		name = "_other"
+	}
+	l.suppressed[name]++
+}
+
+// recordBufferDrop 记录一次因缓冲区满而丢弃的事件
+func (l *rateLimiter) recordBufferDrop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.bufferDropped++
+}
+
+// snapshot 取出并清空统计
+func (l *rateLimiter) snapshot() (map[string]int, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	suppressed, dropped := l.suppressed, l.bufferDropped
+	l.suppressed = make(map[string]int)
+	l.bufferDropped = 0
+	return suppressed, dropped
+}
+
+// runRateLimitReporter 定期发送限流汇总事件
+func (c *Client) runRateLimitReporter() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.limiter.reportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.quit:
+			return
+		case <-ticker.C:
+			c.reportRateLimited()
+		}
+	}
+}
+
+// reportRateLimited 将上一周期被抑制的事件汇总为一个事件，不受限流与采样影响
+func (c *Client) reportRateLimited() {
+	suppressed, dropped := c.limiter.snapshot()
+	total := 0
+	events := make(map[string]interface{}, len(suppressed))
+	for name, n := range suppressed {
+		events[name] = n
+		total += n
+	}
+	if total == 0 && dropped == 0 {
+		return
+	}
+
+	if c.debug && c.logger != nil {
+		c.logger.Printf("[Analytics] Rate limited %d events, dropped %d on full buffer", total, dropped)
+	}
+	c.logAttrs(slog.LevelWarn, "analytics: events rate limited",
+		slog.Int("suppressed", total), slog.Int("buffer_dropped", dropped))
+
+	event := &Event{
+		Name:      RateLimitedEventName,
+		Timestamp: time.Now().Unix(),
+		Properties: map[string]interface{}{
+			"suppressed":       total,
+			"events":           events,
+			"buffer_dropped":   dropped,
+			"interval_seconds": c.limiter.reportInterval.Seconds(),
+		},
+	}
+	if c.consent.allows(event.Consent) {
+		c.push(event)
+	}
+}
@@ -0,0 +1,94 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTokenBucket 测试令牌桶的突发与补充
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(10, 3)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if !b.allow(now) {
+			t.Fatalf("burst token %d rejected", i)
+		}
+	}
+	if b.allow(now) {
+		t.Fatal("allowed beyond burst")
+	}
+	if !b.allow(now.Add(100 * time.Millisecond)) {
+		t.Error("token was not refilled after 100ms at 10/s")
+	}
+	if b.allow(now.Add(100 * time.Millisecond)) {
+		t.Error("allowed more than refilled tokens")
+	}
+}
+
+// TestRateLimitSummary 测试配额与全局限流，以及关闭时发送的汇总事件
+func TestRateLimitSummary(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp",
+		WithFlushInterval(time.Hour),
+		WithEventQuota("loop", 5, time.Hour),
+		WithRateLimit(0.001, 8),
+	)
+
+	for i := 0; i < 100; i++ {
+		client.Track("loop", nil)
+	}
+	for i := 0; i < 5; i++ {
+		client.Track("other", nil)
+	}
+	client.Close()
+
+	counts := make(map[string]int)
+	var summary *Event
+	for _, e := range server.Events() {
+		e := e
+		counts[e.Name]++
+		if e.Name == RateLimitedEventName {
+			summary = &e
+		}
+	}
+	// loop 受配额限制发送 5 个，other 只剩下 3 个令牌
+	if counts["loop"] != 5 || counts["other"] != 3 {
+		t.Errorf("event counts = %v, want loop=5 other=3", counts)
+	}
+	if summary == nil {
+		t.Fatal("no rate limit summary event was sent")
+	}
+	if got := summary.Properties["suppressed"]; got != float64(97) {
+		t.Errorf("suppressed = %v, want 97", got)
+	}
+	events, _ := summary.Properties["events"].(map[string]interface{})
+	if events["loop"] != float64(95) || events["other"] != float64(2) {
+		t.Errorf("suppressed by name = %v, want loop=95 other=2", events)
+	}
+}
+
+// TestRateLimitReporter 测试周期性发送汇总事件
+func TestRateLimitReporter(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp",
+		WithFlushInterval(20*time.Millisecond),
+		WithDefaultEventQuota(1, time.Hour),
+		WithRateLimitReportInterval(20*time.Millisecond),
+	)
+	defer client.Close()
+
+	client.Track("a", nil)
+	client.Track("a", nil)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, e := range server.Events() {
+			if e.Name == RateLimitedEventName {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("summary event was not reported periodically")
+}
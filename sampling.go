@@ -0,0 +1,180 @@
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/rand/v2"
+	"path"
+)
+
+// =============================================================================
+// 事件采样
+// =============================================================================
+
+// SamplingRule 采样规则
+type SamplingRule struct {
+	// Pattern 事件名称，支持 path.Match 通配符，如 "api_*"
+	Pattern string
+
+	// Rate 采样率，取值 [0, 1]，0 表示全部丢弃
+	Rate float64
+}
+
+// SamplingKey 决定采样时按什么分组，同一分组对相同采样率总是得到相同的结果
+type SamplingKey int
+
+const (
+	// SampleByDevice 按设备ID采样（默认），适用于客户端应用
+	SampleByDevice SamplingKey = iota
+
+	// SampleByUser 按事件的 user_id 属性采样，没有时使用 SetUserID 设置的用户；
+	// 两者都没有时逐个事件随机采样。适用于服务端所有事件共用一个设备ID的场景
+	SampleByUser
+
+	// SampleBySession 按事件的 session_id 属性采样，没有时使用客户端当前会话
+	SampleBySession
+
+	// SampleByEvent 逐个事件随机采样
+	SampleByEvent
+)
+
+// sampler 按事件名称确定采样率，并按采样键确定性地决定是否采样
+type sampler struct {
+	exact       map[string]float64
+	globs       []SamplingRule
+	defaultRate float64
+	key         SamplingKey
+}
+
+// WithSamplingRules 添加采样规则
+//
+// 精确匹配的规则优先，其次按添加顺序匹配通配符规则，都不匹配时使用默认采样率。
+// 同一设备对相同采样率总是得到相同的结果，被采样的事件会带上 SampleRate 供服务端还原计数。
+// ConsentEssential 类别的事件不参与采样。
+//
+//	analytics.WithSamplingRules(
+//	    analytics.SamplingRule{Pattern: "http_request", Rate: 0.1},
+//	    analytics.SamplingRule{Pattern: "debug_*", Rate: 0},
+//	)
+func WithSamplingRules(rules ...SamplingRule) ClientOption {
+	return func(c *Client) {
+		s := c.ensureSampler()
+		for _, rule := range rules {
+			rule.Rate = clampRate(rule.Rate)
+			if isGlob(rule.Pattern) {
+				s.globs = append(s.globs, rule)
+			} else {
+				s.exact[rule.Pattern] = rule.Rate
+			}
+		}
+	}
+}
+
+// WithDefaultSampleRate 设置未匹配任何规则的事件的采样率，默认为 1（全部发送）
+func WithDefaultSampleRate(rate float64) ClientOption {
+	return func(c *Client) {
+		c.ensureSampler().defaultRate = clampRate(rate)
+	}
+}
+
+// WithSamplingKey 设置采样分组，默认 SampleByDevice
+//
+// 服务端中间件记录的事件共用服务器的设备ID，按设备采样会让所有请求同时被采样或丢弃，
+// 此时应按用户或会话采样：
+//
+//	analytics.WithSamplingKey(analytics.SampleByUser)
+func WithSamplingKey(key SamplingKey) ClientOption {
+	return func(c *Client) {
+		c.ensureSampler().key = key
+	}
+}
+
+// ensureSampler 返回采样器，不存在时创建
+func (c *Client) ensureSampler() *sampler {
+	if c.sampler == nil {
+		c.sampler = &sampler{exact: make(map[string]float64), defaultRate: 1}
+	}
+	return c.sampler
+}
+
+// rate 返回事件名称对应的采样率
+func (s *sampler) rate(name string) float64 {
+	if rate, ok := s.exact[name]; ok {
+		return rate
+	}
+	for _, rule := range s.globs {
+		if ok, _ := path.Match(rule.Pattern, name); ok {
+			return rule.Rate
+		}
+	}
+	return s.defaultRate
+}
+
+// sample 判断事件是否被采样，被采样时记录采样率
+func (c *Client) sample(event *Event) bool {
+	if c.sampler == nil || event.Consent == ConsentEssential {
+		return true
+	}
+	rate := c.sampler.rate(event.Name)
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 || c.samplingPoint(event) >= rate {
+		return false
+	}
+	event.SampleRate = rate
+	return true
+}
+
+// samplingPoint 按采样键将事件映射到 [0, 1)，没有可用的键时随机取值
+func (c *Client) samplingPoint(event *Event) float64 {
+	var key string
+	switch c.sampler.key {
+	case SampleByDevice:
+		key = c.GetDeviceID()
+	case SampleByUser:
+		if key = stringProperty(event, "user_id"); key == "" {
+			key = c.currentIdentity().userID
+		}
+	case SampleBySession:
+		if key = stringProperty(event, "session_id"); key == "" {
+			key = c.GetSessionID()
+		}
+	}
+	if key == "" {
+		return rand.Float64()
+	}
+	return deviceHash(key)
+}
+
+// stringProperty 返回事件的字符串属性
+func stringProperty(event *Event, name string) string {
+	v, _ := event.Properties[name].(string)
+	return v
+}
+
+// deviceHash 将设备ID（或其他采样键）映射到 [0, 1)
+func deviceHash(deviceID string) float64 {
+	sum := sha256.Sum256([]byte(deviceID))
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+}
+
+// clampRate 将采样率限制在 [0, 1]
+func clampRate(rate float64) float64 {
+	if math.IsNaN(rate) || rate < 0 {
+		return 0
+	}
+	return math.Min(rate, 1)
+}
+
+// isGlob 判断是否包含通配符
+func isGlob(pattern string) bool {
+	for _, r := range pattern {
+		switch r {
+		case '*', '?', '[', '\\':
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// TestSamplerRate 测试采样规则的匹配顺序
+func TestSamplerRate(t *testing.T) {
+	client := NewClient("http://127.0.0.1:0", "TestApp",
+		WithSamplingRules(
+			SamplingRule{Pattern: "api_*", Rate: 0.5},
+			SamplingRule{Pattern: "api_request", Rate: 0.1},
+			SamplingRule{Pattern: "debug_?", Rate: -1},
+		),
+		WithDefaultSampleRate(0.8),
+	)
+	defer client.Close()
+
+	tests := map[string]float64{
+		"api_request": 0.1, // 精确匹配优先于通配符
+		"api_error":   0.5,
+		"debug_x":     0,
+		"page_view":   0.8,
+	}
+	for name, want := range tests {
+		if got := client.sampler.rate(name); got != want {
+			t.Errorf("rate(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// TestSamplingDeterministic 测试同一设备的采样结果稳定，整体比例接近采样率
+func TestSamplingDeterministic(t *testing.T) {
+	const devices = 2000
+	sampled := 0
+	for i := 0; i < devices; i++ {
+		client := &Client{deviceID: fmt.Sprintf("device-%d", i)}
+		WithSamplingRules(SamplingRule{Pattern: "http_request", Rate: 0.25})(client)
+
+		first := client.sample(&Event{Name: "http_request"})
+		for j := 0; j < 3; j++ {
+			if client.sample(&Event{Name: "http_request"}) != first {
+				t.Fatalf("device-%d sampling is not deterministic", i)
+			}
+		}
+		if first {
+			sampled++
+		}
+	}
+	if ratio := float64(sampled) / devices; math.Abs(ratio-0.25) > 0.05 {
+		t.Errorf("sampled ratio = %.3f, want about 0.25", ratio)
+	}
+}
+
+// TestSamplingAttachesRate 测试被采样的事件带上采样率，必要事件不参与采样
+func TestSamplingAttachesRate(t *testing.T) {
+	server := newEventCollector(t)
+
+	// 找到一个会被 0.5 采样率选中的设备
+	deviceID := ""
+	for i := 0; deviceID == ""; i++ {
+		if id := fmt.Sprintf("device-%d", i); deviceHash(id) < 0.5 {
+			deviceID = id
+		}
+	}
+	client := NewClient(server.URL, "TestApp",
+		WithDeviceID(deviceID),
+		WithFlushInterval(time.Hour),
+		WithSamplingRules(SamplingRule{Pattern: "sampled", Rate: 0.5}),
+		WithDefaultSampleRate(0),
+	)
+	client.Track("sampled", nil)
+	client.Track("dropped", nil)
+	client.TrackWithConsent(ConsentEssential, "essential", nil)
+	client.Close()
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("received %d events, want 2: %+v", len(events), events)
+	}
+	if events[0].Name != "sampled" || events[0].SampleRate != 0.5 {
+		t.Errorf("first event = %+v, want sampled with rate 0.5", events[0])
+	}
+	if events[1].Name != "essential" || events[1].SampleRate != 0 {
+		t.Errorf("second event = %+v, want unsampled essential event", events[1])
+	}
+}
+
+// TestSamplingKey 测试按用户、会话或逐个事件采样
+func TestSamplingKey(t *testing.T) {
+	const users = 2000
+	newSampled := func(key SamplingKey) *Client {
+		client := &Client{deviceID: "server", sessionID: "server-session"}
+		WithSamplingRules(SamplingRule{Pattern: "http_request", Rate: 0.25})(client)
+		WithSamplingKey(key)(client)
+		return client
+	}
+
+	client := newSampled(SampleByUser)
+	sampled := 0
+	for i := 0; i < users; i++ {
+		props := map[string]interface{}{"user_id": fmt.Sprintf("user-%d", i)}
+		first := client.sample(&Event{Name: "http_request", Properties: props})
+		if client.sample(&Event{Name: "http_request", Properties: props}) != first {
+			t.Fatalf("user-%d sampling is not deterministic", i)
+		}
+		if first {
+			sampled++
+		}
+	}
+	if ratio := float64(sampled) / users; math.Abs(ratio-0.25) > 0.05 {
+		t.Errorf("user sampled ratio = %.3f, want about 0.25", ratio)
+	}
+
+	// 没有用户时逐个事件采样，而不是按服务器的设备ID全部保留或全部丢弃
+	for _, key := range []SamplingKey{SampleByUser, SampleByEvent} {
+		client := newSampled(key)
+		sampled := 0
+		for i := 0; i < users; i++ {
+			if client.sample(&Event{Name: "http_request"}) {
+				sampled++
+			}
+		}
+		if ratio := float64(sampled) / users; math.Abs(ratio-0.25) > 0.05 {
+			t.Errorf("key %d: sampled ratio = %.3f, want about 0.25", key, ratio)
+		}
+	}
+
+	client = newSampled(SampleBySession)
+	want := deviceHash("server-session") < 0.25
+	for i := 0; i < 10; i++ {
+		if client.sample(&Event{Name: "http_request"}) != want {
+			t.Fatal("session sampling is not deterministic")
+		}
+	}
+}
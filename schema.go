@@ -0,0 +1,472 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// =============================================================================
+// 跟踪计划：按事件名称的 JSON Schema
+// =============================================================================
+
+// TrackingPlan 跟踪计划，为每个事件名称定义属性的 JSON Schema
+//
+// 支持 JSON Schema 的常用关键字：type、enum、const、required、properties、
+// additionalProperties、items、minItems、maxItems、minLength、maxLength、pattern、
+// minimum、maximum、exclusiveMinimum、exclusiveMaximum，其他关键字被忽略。
+//
+// 文件格式：
+//
+//	{
+//	  "events": {
+//	    "purchase": {
+//	      "type": "object",
+//	      "required": ["sku", "amount"],
+//	      "properties": {
+//	        "sku":    {"type": "string"},
+//	        "amount": {"type": "number", "minimum": 0}
+//	      },
+//	      "additionalProperties": false
+//	    }
+//	  },
+//	  "allow_unplanned": false
+//	}
+type TrackingPlan struct {
+	// AllowUnplanned 是否允许计划之外的事件，默认为 true。
+	// SDK 内置事件（崩溃、错误、应用启动/退出等）总是允许。
+	AllowUnplanned bool
+
+	schemas map[string]*schema
+}
+
+// SchemaError 事件违反跟踪计划
+type SchemaError struct {
+	Event  string
+	Errors []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("event %q violates tracking plan: %s", e.Event, strings.Join(e.Errors, "; "))
+}
+
+func (e *SchemaError) Unwrap() error {
+	return ErrSchemaViolation
+}
+
+// builtinEvents SDK 内置的事件，不要求出现在跟踪计划中
+var builtinEvents = map[string]bool{
+	CrashEventName:           true,
+	ErrorEventName:           true,
+	ErrorSuppressedEventName: true,
+	RateLimitedEventName:     true,
+	"app_launch":             true,
+	"app_exit":               true,
+}
+
+// ParseTrackingPlan 解析 JSON 格式的跟踪计划
+func ParseTrackingPlan(data []byte) (*TrackingPlan, error) {
+	var doc struct {
+		Events         map[string]json.RawMessage `json:"events"`
+		AllowUnplanned *bool                      `json:"allow_unplanned"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: tracking plan: %v", ErrUnmarshalFailed, err)
+	}
+
+	plan := &TrackingPlan{AllowUnplanned: true, schemas: make(map[string]*schema, len(doc.Events))}
+	if doc.AllowUnplanned != nil {
+		plan.AllowUnplanned = *doc.AllowUnplanned
+	}
+	for name, raw := range doc.Events {
+		s, err := parseSchema(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: tracking plan event %q: %v", ErrInvalidConfig, name, err)
+		}
+		plan.schemas[name] = s
+	}
+	return plan, nil
+}
+
+// LoadTrackingPlan 从文件加载跟踪计划
+func LoadTrackingPlan(path string) (*TrackingPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTrackingPlan(data)
+}
+
+// Events 返回计划中的事件名称
+func (p *TrackingPlan) Events() []string {
+	names := make([]string, 0, len(p.schemas))
+	for name := range p.schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate 校验事件属性，违反时返回 *SchemaError
+func (p *TrackingPlan) Validate(eventName string, properties map[string]interface{}) error {
+	s, ok := p.schemas[eventName]
+	if !ok {
+		if p.AllowUnplanned || builtinEvents[eventName] {
+			return nil
+		}
+		return &SchemaError{Event: eventName, Errors: []string{"event is not in the tracking plan"}}
+	}
+
+	var props interface{} = properties
+	if properties == nil {
+		props = map[string]interface{}{}
+	}
+	var errs []string
+	s.validate("properties", props, &errs)
+	if len(errs) > 0 {
+		return &SchemaError{Event: eventName, Errors: errs}
+	}
+	return nil
+}
+
+// schema 已解析的 JSON Schema 子集
+type schema struct {
+	types                []string
+	enum                 []string // 规范化后的 JSON
+	constant             *string
+	required             []string
+	properties           map[string]*schema
+	additionalProperties *schema
+	noAdditional         bool
+	items                *schema
+	minItems, maxItems   *int
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	minimum, maximum     *float64
+	exclusiveMin         *float64
+	exclusiveMax         *float64
+}
+
+// parseSchema 解析 JSON Schema
+func parseSchema(raw json.RawMessage) (*schema, error) {
+	var b bool
+	if json.Unmarshal(raw, &b) == nil {
+		// true 接受任何值，false 拒绝任何值
+		if b {
+			return &schema{}, nil
+		}
+		return &schema{enum: []string{}}, nil
+	}
+
+	var doc struct {
+		Type                 json.RawMessage            `json:"type"`
+		Enum                 []json.RawMessage          `json:"enum"`
+		Const                json.RawMessage            `json:"const"`
+		Required             []string                   `json:"required"`
+		Properties           map[string]json.RawMessage `json:"properties"`
+		AdditionalProperties json.RawMessage            `json:"additionalProperties"`
+		Items                json.RawMessage            `json:"items"`
+		MinItems             *int                       `json:"minItems"`
+		MaxItems             *int                       `json:"maxItems"`
+		MinLength            *int                       `json:"minLength"`
+		MaxLength            *int                       `json:"maxLength"`
+		Pattern              string                     `json:"pattern"`
+		Minimum              *float64                   `json:"minimum"`
+		Maximum              *float64                   `json:"maximum"`
+		ExclusiveMinimum     *float64                   `json:"exclusiveMinimum"`
+		ExclusiveMaximum     *float64                   `json:"exclusiveMaximum"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	s := &schema{
+		required:     doc.Required,
+		minItems:     doc.MinItems,
+		maxItems:     doc.MaxItems,
+		minLength:    doc.MinLength,
+		maxLength:    doc.MaxLength,
+		minimum:      doc.Minimum,
+		maximum:      doc.Maximum,
+		exclusiveMin: doc.ExclusiveMinimum,
+		exclusiveMax: doc.ExclusiveMaximum,
+	}
+
+	if len(doc.Type) > 0 {
+		var single string
+		if err := json.Unmarshal(doc.Type, &single); err == nil {
+			s.types = []string{single}
+		} else if err := json.Unmarshal(doc.Type, &s.types); err != nil {
+			return nil, fmt.Errorf("type: %v", err)
+		}
+	}
+	if doc.Enum != nil {
+		s.enum = make([]string, 0, len(doc.Enum))
+		for _, v := range doc.Enum {
+			canonical, err := canonicalJSON(v)
+			if err != nil {
+				return nil, fmt.Errorf("enum: %v", err)
+			}
+			s.enum = append(s.enum, canonical)
+		}
+	}
+	if len(doc.Const) > 0 {
+		canonical, err := canonicalJSON(doc.Const)
+		if err != nil {
+			return nil, fmt.Errorf("const: %v", err)
+		}
+		s.constant = &canonical
+	}
+	if len(doc.Properties) > 0 {
+		s.properties = make(map[string]*schema, len(doc.Properties))
+		for name, raw := range doc.Properties {
+			child, err := parseSchema(raw)
+			if err != nil {
+				return nil, fmt.Errorf("properties.%s: %v", name, err)
+			}
+			s.properties[name] = child
+		}
+	}
+	if len(doc.AdditionalProperties) > 0 {
+		var allowed bool
+		if json.Unmarshal(doc.AdditionalProperties, &allowed) == nil {
+			s.noAdditional = !allowed
+		} else {
+			child, err := parseSchema(doc.AdditionalProperties)
+			if err != nil {
+				return nil, fmt.Errorf("additionalProperties: %v", err)
+			}
+			s.additionalProperties = child
+		}
+	}
+	if len(doc.Items) > 0 {
+		child, err := parseSchema(doc.Items)
+		if err != nil {
+			return nil, fmt.Errorf("items: %v", err)
+		}
+		s.items = child
+	}
+	if doc.Pattern != "" {
+		re, err := regexp.Compile(doc.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern: %v", err)
+		}
+		s.pattern = re
+	}
+	return s, nil
+}
+
+// validate 校验值，错误追加到 errs
+func (s *schema) validate(path string, v interface{}, errs *[]string) {
+	kind, rv := jsonKind(v)
+
+	if len(s.types) > 0 && !s.matchesType(kind, v) {
+		*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(s.types, " or "), kind))
+		return
+	}
+	if s.enum != nil || s.constant != nil {
+		canonical, err := canonicalValue(v)
+		if err != nil {
+			*errs = append(*errs, fmt.Sprintf("%s: %v", path, err))
+			return
+		}
+		if s.constant != nil && canonical != *s.constant {
+			*errs = append(*errs, fmt.Sprintf("%s: must be %s", path, *s.constant))
+		}
+		if s.enum != nil && !containsString(s.enum, canonical) {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is not one of [%s]", path, canonical, strings.Join(s.enum, ", ")))
+		}
+	}
+
+	switch kind {
+	case "string":
+		str := rv.String()
+		n := len([]rune(str))
+		if s.minLength != nil && n < *s.minLength {
+			*errs = append(*errs, fmt.Sprintf("%s: length %d is less than %d", path, n, *s.minLength))
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			*errs = append(*errs, fmt.Sprintf("%s: length %d is greater than %d", path, n, *s.maxLength))
+		}
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			*errs = append(*errs, fmt.Sprintf("%s: does not match pattern %q", path, s.pattern.String()))
+		}
+
+	case "number", "integer":
+		f, _ := numberValue(v)
+		if s.minimum != nil && f < *s.minimum {
+			*errs = append(*errs, fmt.Sprintf("%s: %v is less than minimum %v", path, f, *s.minimum))
+		}
+		if s.maximum != nil && f > *s.maximum {
+			*errs = append(*errs, fmt.Sprintf("%s: %v is greater than maximum %v", path, f, *s.maximum))
+		}
+		if s.exclusiveMin != nil && f <= *s.exclusiveMin {
+			*errs = append(*errs, fmt.Sprintf("%s: %v must be greater than %v", path, f, *s.exclusiveMin))
+		}
+		if s.exclusiveMax != nil && f >= *s.exclusiveMax {
+			*errs = append(*errs, fmt.Sprintf("%s: %v must be less than %v", path, f, *s.exclusiveMax))
+		}
+
+	case "array":
+		n := rv.Len()
+		if s.minItems != nil && n < *s.minItems {
+			*errs = append(*errs, fmt.Sprintf("%s: %d items is less than %d", path, n, *s.minItems))
+		}
+		if s.maxItems != nil && n > *s.maxItems {
+			*errs = append(*errs, fmt.Sprintf("%s: %d items is greater than %d", path, n, *s.maxItems))
+		}
+		if s.items != nil {
+			for i := 0; i < n; i++ {
+				s.items.validate(fmt.Sprintf("%s[%d]", path, i), rv.Index(i).Interface(), errs)
+			}
+		}
+
+	case "object":
+		for _, name := range s.required {
+			if !rv.MapIndex(reflect.ValueOf(name)).IsValid() {
+				*errs = append(*errs, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			name := key.String()
+			value := rv.MapIndex(key).Interface()
+			child := path + "." + name
+			if prop, ok := s.properties[name]; ok {
+				prop.validate(child, value, errs)
+			} else if s.noAdditional {
+				*errs = append(*errs, fmt.Sprintf("%s: unexpected property", child))
+			} else if s.additionalProperties != nil {
+				s.additionalProperties.validate(child, value, errs)
+			}
+		}
+	}
+}
+
+// matchesType 判断值是否匹配任一类型，integer 接受没有小数部分的数字
+func (s *schema) matchesType(kind string, v interface{}) bool {
+	for _, t := range s.types {
+		if t == kind {
+			return true
+		}
+		if t == "number" && kind == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonKind 返回值对应的 JSON 类型
+func jsonKind(v interface{}) (string, reflect.Value) {
+	if v == nil {
+		return "null", reflect.Value{}
+	}
+	if n, ok := v.(json.Number); ok {
+		if _, err := n.Int64(); err == nil {
+			return "integer", reflect.ValueOf(v)
+		}
+		return "number", reflect.ValueOf(v)
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return "null", rv
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return "string", rv
+	case reflect.Bool:
+		return "boolean", rv
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "integer", rv
+	case reflect.Float32, reflect.Float64:
+		if f := rv.Float(); f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer", rv
+		}
+		return "number", rv
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return "null", rv
+		}
+		return "array", rv
+	case reflect.Map:
+		if rv.IsNil() {
+			return "null", rv
+		}
+		if rv.Type().Key().Kind() == reflect.String {
+			return "object", rv
+		}
+	}
+	return rv.Type().String(), rv
+}
+
+// numberValue 返回数值
+func numberValue(v interface{}) (float64, bool) {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	_, rv := jsonKind(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// canonicalJSON 将 JSON 规范化（对象键排序、去除空白）
+func canonicalJSON(raw json.RawMessage) (string, error) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", err
+	}
+	return canonicalValue(v)
+}
+
+// canonicalValue 返回值的规范化 JSON
+func canonicalValue(v interface{}) (string, error) {
+	if n, ok := v.(json.Number); ok {
+		// 与从计划中解析出的 float64 保持一致
+		f, err := n.Float64()
+		if err != nil {
+			return "", err
+		}
+		v = f
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	if kind, _ := jsonKind(v); kind == "integer" || kind == "number" {
+		// 1 与 1.0、int 与 float64 视为相同
+		var f float64
+		if err := json.Unmarshal(data, &f); err == nil {
+			data, _ = json.Marshal(f)
+		}
+	}
+	return string(data), nil
+}
+
+// containsString 判断切片是否包含字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
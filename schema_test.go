@@ -0,0 +1,192 @@
+package analytics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTrackingPlan = `{
+  "events": {
+    "purchase": {
+      "type": "object",
+      "required": ["sku", "amount"],
+      "properties": {
+        "sku":      {"type": "string", "pattern": "^[A-Z]{3}-[0-9]+$"},
+        "amount":   {"type": "number", "minimum": 0},
+        "currency": {"enum": ["USD", "EUR"]},
+        "tags":     {"type": "array", "items": {"type": "string"}, "maxItems": 2}
+      },
+      "additionalProperties": false
+    },
+    "signup": {"type": "object", "properties": {"step": {"type": "integer"}}}
+  },
+  "allow_unplanned": false
+}`
+
+// mustPlan 解析测试用跟踪计划
+func mustPlan(t *testing.T) *TrackingPlan {
+	t.Helper()
+	plan, err := ParseTrackingPlan([]byte(testTrackingPlan))
+	if err != nil {
+		t.Fatalf("ParseTrackingPlan() error = %v", err)
+	}
+	return plan
+}
+
+// TestTrackingPlanValidate 测试各关键字的校验结果
+func TestTrackingPlanValidate(t *testing.T) {
+	plan := mustPlan(t)
+
+	tests := []struct {
+		name  string
+		event string
+		props map[string]interface{}
+		want  string // 期望错误中包含的片段，空表示通过
+	}{
+		{"valid", "purchase", map[string]interface{}{"sku": "ABC-1", "amount": 9.5, "currency": "USD"}, ""},
+		{"typed slice", "purchase", map[string]interface{}{"sku": "ABC-1", "amount": 1, "tags": []string{"a"}}, ""},
+		{"missing required", "purchase", map[string]interface{}{"sku": "ABC-1"}, "amount"},
+		{"wrong type", "purchase", map[string]interface{}{"sku": "ABC-1", "amount": "9"}, "properties.amount"},
+		{"below minimum", "purchase", map[string]interface{}{"sku": "ABC-1", "amount": -1}, "properties.amount"},
+		{"pattern", "purchase", map[string]interface{}{"sku": "abc", "amount": 1}, "properties.sku"},
+		{"enum", "purchase", map[string]interface{}{"sku": "ABC-1", "amount": 1, "currency": "GBP"}, "properties.currency"},
+		{"items", "purchase", map[string]interface{}{"sku": "ABC-1", "amount": 1, "tags": []interface{}{1}}, "properties.tags[0]"},
+		{"max items", "purchase", map[string]interface{}{"sku": "ABC-1", "amount": 1, "tags": []string{"a", "b", "c"}}, "properties.tags"},
+		{"additional", "purchase", map[string]interface{}{"sku": "ABC-1", "amount": 1, "coupon": "X"}, "coupon"},
+		{"integer", "signup", map[string]interface{}{"step": 1.5}, "properties.step"},
+		{"integer valued float", "signup", map[string]interface{}{"step": 2.0}, ""},
+		{"unplanned", "page_view", nil, "not in the tracking plan"},
+		{"builtin", CrashEventName, map[string]interface{}{"anything": true}, ""},
+		{"builtin suppressed errors", ErrorSuppressedEventName, map[string]interface{}{"fingerprint": "f", "suppressed_count": 2}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := plan.Validate(tt.event, tt.props)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) || !errors.Is(err, ErrSchemaViolation) {
+				t.Fatalf("Validate() error = %v, want *SchemaError", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseTrackingPlanInvalid 测试无效的计划在加载时报错
+func TestParseTrackingPlanInvalid(t *testing.T) {
+	for _, data := range []string{
+		`{"events": `,
+		`{"events": {"a": {"type": 1}}}`,
+		`{"events": {"a": {"pattern": "("}}}`,
+	} {
+		if _, err := ParseTrackingPlan([]byte(data)); err == nil {
+			t.Errorf("ParseTrackingPlan(%s) error = nil, want error", data)
+		}
+	}
+}
+
+// TestSchemaActions 测试三种处理方式以及违规报告
+func TestSchemaActions(t *testing.T) {
+	invalid := map[string]interface{}{"sku": "ABC-1"}
+	valid := map[string]interface{}{"sku": "ABC-1", "amount": 1}
+
+	t.Run("drop", func(t *testing.T) {
+		server := newEventCollector(t)
+		client := NewClient(server.URL, "TestApp", WithFlushInterval(time.Hour),
+			WithTrackingPlan(mustPlan(t), SchemaDrop))
+		if err := client.TrackStrict("purchase", invalid); err != nil {
+			t.Errorf("TrackStrict() error = %v, want nil in drop mode", err)
+		}
+		client.Track("purchase", valid)
+		client.Close()
+
+		if events := server.Events(); len(events) != 1 {
+			t.Fatalf("sent %d events, want 1", len(events))
+		}
+		report := client.SchemaViolations()
+		if len(report) != 1 || report[0].Event != "purchase" || report[0].Count != 1 {
+			t.Errorf("SchemaViolations() = %+v", report)
+		}
+	})
+
+	t.Run("tag", func(t *testing.T) {
+		server := newEventCollector(t)
+		client := NewClient(server.URL, "TestApp", WithFlushInterval(time.Hour),
+			WithTrackingPlan(mustPlan(t), SchemaTag))
+		if err := client.TrackStrict("purchase", invalid); err != nil {
+			t.Errorf("TrackStrict() error = %v, want nil in tag mode", err)
+		}
+		client.Close()
+
+		if _, ok := invalid[SchemaErrorsProperty]; ok {
+			t.Error("caller's properties were modified")
+		}
+		events := server.Events()
+		if len(events) != 1 {
+			t.Fatalf("sent %d events, want 1", len(events))
+		}
+		if _, ok := events[0].Properties[SchemaErrorsProperty]; !ok {
+			t.Errorf("properties = %v, want %s", events[0].Properties, SchemaErrorsProperty)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		server := newEventCollector(t)
+		client := NewClient(server.URL, "TestApp", WithFlushInterval(time.Hour),
+			WithTrackingPlan(mustPlan(t), SchemaReject))
+		err := client.TrackStrict("purchase", invalid)
+		var schemaErr *SchemaError
+		if !errors.As(err, &schemaErr) || schemaErr.Event != "purchase" {
+			t.Errorf("TrackStrict() error = %v, want *SchemaError", err)
+		}
+		if err := client.TrackStrict("purchase", valid); err != nil {
+			t.Errorf("TrackStrict(valid) error = %v", err)
+		}
+		client.Close()
+
+		if events := server.Events(); len(events) != 1 {
+			t.Errorf("sent %d events, want 1", len(events))
+		}
+	})
+}
+
+// TestTrackStrictRateLimited 测试 TrackStrict 返回限流原因
+func TestTrackStrictRateLimited(t *testing.T) {
+	client := NewClient("http://127.0.0.1:0", "TestApp", WithFlushInterval(time.Hour),
+		WithRateLimit(0.001, 1))
+	defer client.Close()
+
+	if err := client.TrackStrict("a", nil); err != nil {
+		t.Fatalf("first TrackStrict() error = %v", err)
+	}
+	if err := client.TrackStrict("a", nil); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("second TrackStrict() error = %v, want ErrRateLimited", err)
+	}
+}
+
+// TestTrackSyncSchema 测试同步发送同样按跟踪计划校验
+func TestTrackSyncSchema(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp", WithTrackingPlan(mustPlan(t), SchemaReject))
+	defer client.Close()
+
+	var schemaErr *SchemaError
+	if err := client.TrackSync("purchase", map[string]interface{}{"sku": "ABC-1"}); !errors.As(err, &schemaErr) {
+		t.Errorf("TrackSync(invalid) error = %v, want *SchemaError", err)
+	}
+	if err := client.TrackSync("purchase", map[string]interface{}{"sku": "ABC-1", "amount": 1}); err != nil {
+		t.Errorf("TrackSync(valid) error = %v", err)
+	}
+	if events := server.Events(); len(events) != 1 {
+		t.Errorf("sent %d events, want 1", len(events))
+	}
+}
@@ -0,0 +1,92 @@
+package analytics
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// =============================================================================
+// 并发发送
+// =============================================================================
+
+// WithSenderConcurrency 设置同时发送的批次数，默认为 1（串行发送）
+//
+// 组好的批次交给 n 个发送 goroutine，进行中的批次最多为 2n 个，
+// 全部繁忙时后台处理会等待，新事件暂存在缓冲区中。
+func WithSenderConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		if n > 0 {
+			c.senders = n
+		}
+	}
+}
+
+// WithSessionOrdering 保证同一会话的事件按入队顺序发送
+//
+// 事件按 Properties["session_id"]（没有时使用客户端会话）分配到固定的发送 goroutine，
+// 同一会话的批次串行发送；不同会话之间仍然并发。仅在 WithSenderConcurrency 大于 1 时有意义。
+func WithSessionOrdering() ClientOption {
+	return func(c *Client) {
+		c.sessionOrdering = true
+	}
+}
+
+// lanes 返回组批通道数：按会话排序时每个发送 goroutine 一个，否则共用一个
+func (c *Client) lanes() int {
+	if c.senders > 1 && c.sessionOrdering {
+		return c.senders
+	}
+	return 1
+}
+
+// laneOf 返回事件所属的组批通道
+func (c *Client) laneOf(event *Event) int {
+	lanes := c.lanes()
+	if lanes == 1 {
+		return 0
+	}
+	session, _ := event.Properties["session_id"].(string)
+	if session == "" {
+		session = c.GetSessionID()
+	}
+	h := fnv.New32a()
+	h.Write([]byte(session))
+	return int(h.Sum32() % uint32(lanes))
+}
+
+// startSenders 启动发送 goroutine，返回投递批次的函数与停止函数
+//
+// 停止函数等待所有已投递的批次发送完成。并发为 1 时在调用方 goroutine 中同步发送。
+func (c *Client) startSenders() (send func(lane int, batch []*Event), stop func()) {
+	if c.senders <= 1 {
+		return func(_ int, batch []*Event) { c.sendEvents(c.ctx, batch) }, func() {}
+	}
+
+	// 按会话排序时每个通道独占一个队列与发送 goroutine，否则所有 goroutine 共用一个队列
+	queues := make([]chan []*Event, c.lanes())
+	for i := range queues {
+		queues[i] = make(chan []*Event, c.senders/len(queues))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.senders; i++ {
+		wg.Add(1)
+		go func(queue chan []*Event) {
+			defer wg.Done()
+			for batch := range queue {
+				c.sendEvents(c.ctx, batch)
+			}
+		}(queues[i%len(queues)])
+	}
+
+	send = func(lane int, batch []*Event) {
+		queues[lane] <- batch
+	}
+	stop = func() {
+		for _, q := range queues {
+			close(q)
+		}
+		wg.Wait()
+	}
+	return send, stop
+}
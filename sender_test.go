@@ -0,0 +1,157 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowServer 每个请求延迟 latency 后响应，并记录最大并发数与事件到达顺序
+type slowServer struct {
+	*httptest.Server
+
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+
+	mu     sync.Mutex
+	events []Event
+}
+
+func newSlowServer(tb testing.TB, latency func() time.Duration) *slowServer {
+	tb.Helper()
+	s := &slowServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		for {
+			max := s.maxInFlight.Load()
+			if n <= max || s.maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+
+		var payload struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		time.Sleep(latency())
+
+		s.mu.Lock()
+		s.events = append(s.events, payload.Events...)
+		s.mu.Unlock()
+	}))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+func (s *slowServer) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+// TestSenderConcurrency 测试并发发送且进行中的批次数有上限
+func TestSenderConcurrency(t *testing.T) {
+	server := newSlowServer(t, func() time.Duration { return 30 * time.Millisecond })
+	client := NewClient(server.URL, "TestApp",
+		WithBatchSize(1),
+		WithSenderConcurrency(4),
+	)
+
+	for i := 0; i < 16; i++ {
+		client.Track("event", nil)
+	}
+	client.Close()
+
+	if got := len(server.Events()); got != 16 {
+		t.Errorf("received %d events, want 16", got)
+	}
+	if max := server.maxInFlight.Load(); max < 2 || max > 4 {
+		t.Errorf("max in-flight batches = %d, want 2..4", max)
+	}
+}
+
+// TestSessionOrdering 测试同一会话的事件按顺序到达
+func TestSessionOrdering(t *testing.T) {
+	var calls atomic.Int32
+	server := newSlowServer(t, func() time.Duration {
+		// 交替的延迟让无序发送很容易乱序
+		return time.Duration(calls.Add(1)%3) * 10 * time.Millisecond
+	})
+	client := NewClient(server.URL, "TestApp",
+		WithBatchSize(1),
+		WithSenderConcurrency(4),
+		WithSessionOrdering(),
+	)
+
+	const sessions, perSession = 4, 6
+	for seq := 0; seq < perSession; seq++ {
+		for s := 0; s < sessions; s++ {
+			client.Track("step", map[string]interface{}{
+				"session_id": fmt.Sprintf("s-%d", s),
+				"seq":        seq,
+			})
+		}
+	}
+	client.Close()
+
+	events := server.Events()
+	if len(events) != sessions*perSession {
+		t.Fatalf("received %d events, want %d", len(events), sessions*perSession)
+	}
+	last := make(map[string]float64)
+	for _, e := range events {
+		session := e.Properties["session_id"].(string)
+		seq := e.Properties["seq"].(float64)
+		if prev, ok := last[session]; ok && seq < prev {
+			t.Errorf("session %s: seq %v arrived after %v", session, seq, prev)
+		}
+		last[session] = seq
+	}
+}
+
+// TestLaneOfClientSession 测试没有 session_id 的事件按客户端会话分配通道
+func TestLaneOfClientSession(t *testing.T) {
+	client := NewClient("http://127.0.0.1:0", "TestApp", WithSenderConcurrency(16), WithSessionOrdering())
+	defer client.Close()
+
+	// 选一个与空字符串不在同一通道的会话，避免哈希碰撞让测试恒过
+	h := fnv.New32a()
+	empty := int(h.Sum32() % 16)
+	for i := 0; i < 100; i++ {
+		client.sessionID = fmt.Sprintf("session-%d", i)
+		if client.laneOf(&Event{Properties: map[string]interface{}{"session_id": client.sessionID}}) != empty {
+			break
+		}
+	}
+	want := client.laneOf(&Event{Properties: map[string]interface{}{"session_id": client.sessionID}})
+	if got := client.laneOf(&Event{Name: "no_session"}); got != want {
+		t.Errorf("laneOf(no session) = %d, want client session lane %d", got, want)
+	}
+}
+
+// BenchmarkSenderConcurrency 对比不同发送并发数的吞吐量（服务端延迟 2ms）
+func BenchmarkSenderConcurrency(b *testing.B) {
+	server := newSlowServer(b, func() time.Duration { return 2 * time.Millisecond })
+
+	for _, senders := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("senders=%d", senders), func(b *testing.B) {
+			client := NewClient(server.URL, "BenchApp",
+				WithBatchSize(20),
+				WithBufferSize(b.N+1),
+				WithSenderConcurrency(senders),
+			)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				client.Track("bench", nil)
+			}
+			client.Close()
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package analytics
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 请求签名（HMAC-SHA256）
+// =============================================================================
+
+// 签名相关的请求头
+const (
+	// HeaderSignature 请求签名（十六进制 HMAC-SHA256）
+	HeaderSignature = "X-Analytics-Signature"
+
+	// HeaderTimestamp 签名时间戳（Unix 秒）
+	HeaderTimestamp = "X-Analytics-Timestamp"
+
+	// HeaderNonce 一次性随机数，用于防重放
+	HeaderNonce = "X-Analytics-Nonce"
+)
+
+// DefaultSignatureMaxSkew 服务端校验时允许的默认时间偏差
+const DefaultSignatureMaxSkew = 5 * time.Minute
+
+// DefaultSignatureMaxBodySize Verify 默认读取的最大请求体字节数
+const DefaultSignatureMaxBodySize = 10 << 20
+
+// WithSigningKey 启用 HMAC-SHA256 请求签名
+//
+// 启用后，/api/events/batch 与 /api/installs/push 请求都会携带
+// X-Analytics-Timestamp、X-Analytics-Nonce 和 X-Analytics-Signature 请求头。
+// 签名覆盖请求方法、路径、时间戳、随机数以及请求体的 SHA256 摘要，
+// 服务端可使用 SignatureVerifier 校验。
+func WithSigningKey(secret string) ClientOption {
+	return func(c *Client) {
+		c.signingKey = []byte(secret)
+	}
+}
+
+// SignRequest 计算请求签名
+//
+// 签名原文格式:
+//
+//	METHOD\nPATH\nTIMESTAMP\nNONCE\nHEX(SHA256(BODY))
+//
+// 返回十六进制编码的 HMAC-SHA256 值。
+func SignRequest(secret []byte, method, path string, timestamp int64, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	canonical := fmt.Sprintf("%s\n%s\n%d\n%s\n%s",
+		method, path, timestamp, nonce, hex.EncodeToString(bodyHash[:]))
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signRequest 为请求添加签名头（未配置签名密钥时不做任何处理）
+func (c *Client) signRequest(req *http.Request, body []byte) error {
+	if len(c.signingKey) == 0 {
+		return nil
+	}
+
+	nonce, err := generateNonce()
+	if err != nil {
+		return newClientError("signRequest", err)
+	}
+	timestamp := time.Now().Unix()
+	signature := SignRequest(c.signingKey, req.Method, req.URL.Path, timestamp, nonce, body)
+
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderSignature, signature)
+	return nil
+}
+
+// generateNonce 生成 16 字节随机数的十六进制表示
+func generateNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// =============================================================================
+// SignatureVerifier - 服务端签名校验
+// =============================================================================
+
+// SignatureVerifier 校验客户端请求签名，并拒绝过期或重放的请求
+//
+// 用于采集服务端：
+//
+//	verifier := analytics.NewSignatureVerifier(secret, analytics.DefaultSignatureMaxSkew)
+//	body, err := verifier.Verify(r)
+//	if err != nil {
+//	    http.Error(w, "forbidden", http.StatusForbidden)
+//	    return
+//	}
+//
+// 已使用过的 nonce 会在内存中保留到请求时间戳之后 maxSkew，即该请求不再能通过时间校验为止。多实例部署时，
+// 应在负载均衡层保证会话粘性，或自行实现共享的 nonce 存储。
+type SignatureVerifier struct {
+	secret  []byte
+	maxSkew time.Duration
+	maxBody int64
+	now     func() time.Time
+
+	mu        sync.Mutex
+	nonces    map[string]int64 // nonce -> 过期时间
+	lastSweep int64
+}
+
+// NewSignatureVerifier 创建签名校验器
+//
+// maxSkew 为请求时间戳与服务端时间之间允许的最大偏差，<= 0 时使用 DefaultSignatureMaxSkew。
+func NewSignatureVerifier(secret string, maxSkew time.Duration) *SignatureVerifier {
+	if maxSkew <= 0 {
+		maxSkew = DefaultSignatureMaxSkew
+	}
+	return &SignatureVerifier{
+		secret:  []byte(secret),
+		maxSkew: maxSkew,
+		maxBody: DefaultSignatureMaxBodySize,
+		now:     time.Now,
+		nonces:  make(map[string]int64),
+	}
+}
+
+// SetMaxBodySize 设置 Verify 读取的最大请求体字节数，<= 0 时恢复为 DefaultSignatureMaxBodySize
+//
+// 应在开始校验请求前调用。
+func (v *SignatureVerifier) SetMaxBodySize(n int64) {
+	if n <= 0 {
+		n = DefaultSignatureMaxBodySize
+	}
+	v.maxBody = n
+}
+
+// Verify 校验请求签名
+//
+// 成功时返回完整的请求体，并将 r.Body 重置为可再次读取的状态。
+// 签名校验前需要读取完整的请求体，超过 SetMaxBodySize 设置的大小时返回 ErrRequestTooLarge。
+func (v *SignatureVerifier) Verify(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, v.maxBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err = fmt.Errorf("%w: limit is %d bytes", ErrRequestTooLarge, tooLarge.Limit)
+		}
+		return nil, newClientError("Verify", err)
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := v.VerifySignature(r.Method, r.URL.Path, r.Header, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// VerifySignature 使用已读取的请求体校验签名
+func (v *SignatureVerifier) VerifySignature(method, path string, header http.Header, body []byte) error {
+	signature := header.Get(HeaderSignature)
+	nonce := header.Get(HeaderNonce)
+	tsHeader := header.Get(HeaderTimestamp)
+	if signature == "" || nonce == "" || tsHeader == "" {
+		return newClientError("VerifySignature", ErrMissingSignature)
+	}
+
+	timestamp, err := strconv.ParseInt(tsHeader, 10, 64)
+	if err != nil {
+		return newClientError("VerifySignature", fmt.Errorf("%w: bad timestamp", ErrInvalidSignature))
+	}
+
+	now := v.now()
+	skew := now.Sub(time.Unix(timestamp, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > v.maxSkew {
+		return newClientError("VerifySignature", ErrSignatureExpired)
+	}
+
+	expected := SignRequest(v.secret, method, path, timestamp, nonce, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return newClientError("VerifySignature", ErrInvalidSignature)
+	}
+
+	// 签名有效后再登记 nonce，避免伪造请求占用 nonce
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	nowUnix := now.Unix()
+	if nowUnix != v.lastSweep {
+		for n, expires := range v.nonces {
+			if expires < nowUnix {
+				delete(v.nonces, n)
+			}
+		}
+		v.lastSweep = nowUnix
+	}
+	if _, seen := v.nonces[nonce]; seen {
+		return newClientError("VerifySignature", ErrReplayedRequest)
+	}
+	// 过期时间以请求时间戳计算：时间戳最多可比服务端时间超前 maxSkew，
+	// 按服务端时间计算会让未来时间戳的请求在 nonce 被清理后仍然有效
+	v.nonces[nonce] = timestamp + int64((v.maxSkew+time.Second-1)/time.Second) + 1
+	return nil
+}
@@ -0,0 +1,167 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSigningRoundTrip 测试客户端签名可被服务端校验通过
+func TestSigningRoundTrip(t *testing.T) {
+	verifier := NewSignatureVerifier("s3cret", time.Minute)
+
+	var mu sync.Mutex
+	var verifyErrs []error
+	paths := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := verifier.Verify(r)
+		mu.Lock()
+		paths[r.URL.Path] = true
+		if err != nil {
+			verifyErrs = append(verifyErrs, err)
+		}
+		mu.Unlock()
+		if err != nil {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "TestApp", WithSigningKey("s3cret"))
+	defer client.Close()
+
+	if err := client.sendEvents(context.Background(), []*Event{{Name: "signed", Timestamp: time.Now().Unix()}}); err != nil {
+		t.Fatalf("sendEvents() error = %v", err)
+	}
+	if err := client.sendInstallInfo(context.Background(), &InstallInfo{Product: "TestApp", DeviceID: "d1"}); err != nil {
+		t.Fatalf("sendInstallInfo() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(verifyErrs) > 0 {
+		t.Errorf("Verify() errors = %v", verifyErrs)
+	}
+	for _, p := range []string{"/api/events/batch", "/api/installs/push"} {
+		if !paths[p] {
+			t.Errorf("request to %s not received", p)
+		}
+	}
+}
+
+// TestSignatureVerifier 测试签名校验的各类失败场景
+func TestSignatureVerifier(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"events":[]}`)
+
+	newRequest := func(secret string, ts int64, nonce string, payload []byte) *http.Request {
+		r := httptest.NewRequest("POST", "/api/events/batch", strings.NewReader(string(payload)))
+		r.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+		r.Header.Set(HeaderNonce, nonce)
+		r.Header.Set(HeaderSignature, SignRequest([]byte(secret), "POST", "/api/events/batch", ts, nonce, body))
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		req     *http.Request
+		wantErr error
+	}{
+		{"有效签名", newRequest("key", now.Unix(), "n1", body), nil},
+		{"重放请求", newRequest("key", now.Unix(), "n1", body), ErrReplayedRequest},
+		{"错误密钥", newRequest("other", now.Unix(), "n2", body), ErrInvalidSignature},
+		{"请求体被篡改", newRequest("key", now.Unix(), "n3", []byte(`{"events":[1]}`)), ErrInvalidSignature},
+		{"时间戳过期", newRequest("key", now.Add(-time.Hour).Unix(), "n4", body), ErrSignatureExpired},
+		{"缺少签名", httptest.NewRequest("POST", "/api/events/batch", nil), ErrMissingSignature},
+	}
+
+	verifier := NewSignatureVerifier("key", time.Minute)
+	verifier.now = func() time.Time { return now }
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(tt.req)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if string(got) != string(body) {
+					t.Errorf("Verify() body = %s, want %s", got, body)
+				}
+				rest, _ := io.ReadAll(tt.req.Body)
+				if string(rest) != string(body) {
+					t.Errorf("request body not restored: %s", rest)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestSignatureVerifierFutureTimestampReplay 测试时间戳超前的请求在有效期内不能被重放
+func TestSignatureVerifierFutureTimestampReplay(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	now := start
+	body := []byte(`{"events":[]}`)
+	ts := start.Add(time.Minute).Unix() // 超前 maxSkew
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest("POST", "/api/events/batch", strings.NewReader(string(body)))
+		r.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+		r.Header.Set(HeaderNonce, "future")
+		r.Header.Set(HeaderSignature, SignRequest([]byte("key"), "POST", "/api/events/batch", ts, "future", body))
+		return r
+	}
+
+	verifier := NewSignatureVerifier("key", time.Minute)
+	verifier.now = func() time.Time { return now }
+	if _, err := verifier.Verify(newRequest()); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	// 按服务端时间计算时，nonce 此时已被清理，而时间戳仍在允许的偏差内
+	for _, elapsed := range []time.Duration{61 * time.Second, 90 * time.Second, 2 * time.Minute} {
+		now = start.Add(elapsed)
+		if _, err := verifier.Verify(newRequest()); !errors.Is(err, ErrReplayedRequest) {
+			t.Errorf("replay after %v: Verify() error = %v, want ErrReplayedRequest", elapsed, err)
+		}
+	}
+	now = start.Add(2*time.Minute + 2*time.Second)
+	if _, err := verifier.Verify(newRequest()); !errors.Is(err, ErrSignatureExpired) {
+		t.Errorf("replay after expiry: Verify() error = %v, want ErrSignatureExpired", err)
+	}
+}
+
+// TestSignatureVerifierMaxBodySize 测试超过大小限制的请求体在校验签名前被拒绝
+func TestSignatureVerifierMaxBodySize(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewSignatureVerifier("key", time.Minute)
+	verifier.now = func() time.Time { return now }
+	verifier.SetMaxBodySize(16)
+
+	newRequest := func(nonce string, body []byte) *http.Request {
+		r := httptest.NewRequest("POST", "/api/events/batch", strings.NewReader(string(body)))
+		r.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+		r.Header.Set(HeaderNonce, nonce)
+		r.Header.Set(HeaderSignature, SignRequest([]byte("key"), "POST", "/api/events/batch", now.Unix(), nonce, body))
+		return r
+	}
+
+	if _, err := verifier.Verify(newRequest("n1", []byte(`{"events":[]}`))); err != nil {
+		t.Fatalf("Verify() within limit error = %v", err)
+	}
+	large := []byte(`{"events":[1,2,3,4,5]}`)
+	if _, err := verifier.Verify(newRequest("n2", large)); !errors.Is(err, ErrRequestTooLarge) {
+		t.Errorf("Verify() over limit error = %v, want ErrRequestTooLarge", err)
+	}
+}
@@ -0,0 +1,268 @@
+package analytics
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// log/slog 集成
+// =============================================================================
+
+// slogInternalKey 标记客户端自身产生的日志，SlogHandler 不会将其转换为事件，
+// 避免“日志 -> 事件 -> 日志”的循环
+type slogInternalKey struct{}
+
+// WithSlogLogger 设置结构化日志器
+//
+// 与 WithLogger 不同，slog 日志不受 WithDebug 控制，按日志级别输出：
+//   - Debug: 批次发送成功（batch_size、status_code、url、latency）
+//   - Warn:  批次发送失败、事件因缓冲区满被丢弃
+//   - Error: 序列化或加密失败
+func WithSlogLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.slogger = logger
+		if logger != nil {
+			c.observers = append(c.observers, &slogSendObserver{client: c})
+		}
+	}
+}
+
+// logAttrs 输出结构化日志（未设置 slog 日志器时不做任何处理）
+func (c *Client) logAttrs(level slog.Level, msg string, attrs ...slog.Attr) {
+	if c.slogger == nil {
+		return
+	}
+	ctx := context.WithValue(context.Background(), slogInternalKey{}, true)
+	if !c.slogger.Enabled(ctx, level) {
+		return
+	}
+	c.slogger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// slogSendObserver 为每次批量发送输出结构化日志
+type slogSendObserver struct {
+	client *Client
+}
+
+// StartSend 实现 SendObserver
+func (o *slogSendObserver) StartSend(ctx context.Context, info SendInfo) (context.Context, func(SendResult)) {
+	return ctx, func(r SendResult) {
+		attrs := []slog.Attr{
+			slog.Int("batch_size", info.BatchSize),
+			slog.Int("bytes", info.Bytes),
+			slog.String("url", info.URL),
+			slog.Int("status_code", r.StatusCode),
+			slog.Duration("latency", r.Duration),
+		}
+		if r.Err != nil {
+			attrs = append(attrs, slog.Any("error", r.Err), slog.Bool("retryable", isRetryableError(r.Err)))
+			o.client.logAttrs(slog.LevelWarn, "analytics: send events failed", attrs...)
+			return
+		}
+		o.client.logAttrs(slog.LevelDebug, "analytics: events sent", attrs...)
+	}
+}
+
+// =============================================================================
+// SlogHandler - 将日志记录转换为分析事件
+// =============================================================================
+
+// SlogHandlerOptions SlogHandler 配置
+type SlogHandlerOptions struct {
+	// EventName 生成的事件名称，默认为 "log"
+	EventName string
+
+	// Level 级别不低于该值的记录会被转换为事件，默认为 slog.LevelWarn
+	Level slog.Leveler
+
+	// TrackAttr 带有该属性键的记录无论级别都会被转换为事件（可选）
+	TrackAttr string
+
+	// Filter 自定义筛选函数，设置后替代 Level 与 TrackAttr 规则（可选）
+	Filter func(ctx context.Context, r slog.Record) bool
+}
+
+// SlogHandler 是一个 slog.Handler，将选中的日志记录通过 Track 发送为分析事件，
+// 并将所有记录继续交给下游 Handler 处理
+//
+//	handler := analytics.NewSlogHandler(client, slog.NewJSONHandler(os.Stderr, nil), nil)
+//	logger := slog.New(handler)
+//	logger.Warn("payment declined", "provider", "stripe") // 同时成为 "log" 事件
+//
+// 事件属性包括 level、message，以及记录的所有属性（分组属性以 "." 连接键名）。
+type SlogHandler struct {
+	client *Client
+	next   slog.Handler
+	opts   SlogHandlerOptions
+	attrs  []slog.Attr
+	groups []string
+}
+
+// NewSlogHandler 创建 SlogHandler，next 可为 nil（仅发送事件）
+func NewSlogHandler(client *Client, next slog.Handler, opts *SlogHandlerOptions) *SlogHandler {
+	h := &SlogHandler{client: client, next: next}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.EventName == "" {
+		h.opts.EventName = "log"
+	}
+	if h.opts.Level == nil {
+		h.opts.Level = slog.LevelWarn
+	}
+	return h
+}
+
+// Enabled 实现 slog.Handler
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.next != nil && h.next.Enabled(ctx, level) {
+		return true
+	}
+	if h.opts.Filter != nil || h.opts.TrackAttr != "" {
+		return true
+	}
+	return level >= h.opts.Level.Level()
+}
+
+// Handle 实现 slog.Handler
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil || ctx.Value(slogInternalKey{}) == nil {
+		if h.shouldTrack(ctx, r) {
+			h.client.TrackContext(ctx, h.opts.EventName, h.recordProperties(r))
+		}
+	}
+
+	if h.next != nil && h.next.Enabled(ctx, r.Level) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+// WithAttrs 实现 slog.Handler
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	prefix := strings.Join(h.groups, ".")
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), prefixAttrs(prefix, attrs)...)
+	if h.next != nil {
+		clone.next = h.next.WithAttrs(attrs)
+	}
+	return &clone
+}
+
+// WithGroup 实现 slog.Handler
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.groups = append(append([]string(nil), h.groups...), name)
+	if h.next != nil {
+		clone.next = h.next.WithGroup(name)
+	}
+	return &clone
+}
+
+// shouldTrack 判断记录是否需要转换为事件
+func (h *SlogHandler) shouldTrack(ctx context.Context, r slog.Record) bool {
+	if h.opts.Filter != nil {
+		return h.opts.Filter(ctx, r)
+	}
+	if r.Level >= h.opts.Level.Level() {
+		return true
+	}
+	if h.opts.TrackAttr == "" {
+		return false
+	}
+	for _, a := range h.attrs {
+		if a.Key == h.opts.TrackAttr {
+			return true
+		}
+	}
+	found := false
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == h.opts.TrackAttr {
+			found = true
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// recordProperties 将日志记录转换为事件属性
+func (h *SlogHandler) recordProperties(r slog.Record) map[string]interface{} {
+	props := map[string]interface{}{
+		"level":   r.Level.String(),
+		"message": r.Message,
+	}
+	for _, a := range h.attrs {
+		flattenAttr(props, "", a)
+	}
+	prefix := strings.Join(h.groups, ".")
+	r.Attrs(func(a slog.Attr) bool {
+		flattenAttr(props, prefix, a)
+		return true
+	})
+	return props
+}
+
+// prefixAttrs 为属性键添加分组前缀
+func prefixAttrs(prefix string, attrs []slog.Attr) []slog.Attr {
+	if prefix == "" {
+		return attrs
+	}
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		out[i] = slog.Attr{Key: prefix + "." + a.Key, Value: a.Value}
+	}
+	return out
+}
+
+// flattenAttr 将属性（包括分组）展开写入 props
+func flattenAttr(props map[string]interface{}, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	key := a.Key
+	if prefix != "" && key != "" {
+		key = prefix + "." + key
+	} else if key == "" {
+		key = prefix
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			flattenAttr(props, key, ga)
+		}
+		return
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		props[key] = a.Value.String()
+	case slog.KindInt64:
+		props[key] = a.Value.Int64()
+	case slog.KindUint64:
+		props[key] = a.Value.Uint64()
+	case slog.KindFloat64:
+		props[key] = a.Value.Float64()
+	case slog.KindBool:
+		props[key] = a.Value.Bool()
+	case slog.KindDuration:
+		props[key] = a.Value.Duration().String()
+	case slog.KindTime:
+		props[key] = a.Value.Time().Format(time.RFC3339Nano)
+	default:
+		v := a.Value.Any()
+		if err, ok := v.(error); ok {
+			props[key] = err.Error()
+		} else {
+			props[key] = v
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestWithSlogLogger 测试发送结果输出结构化日志
+func TestWithSlogLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client := NewClient(server.URL, "TestApp", WithSlogLogger(logger))
+	defer client.Close()
+	client.sendEvents(context.Background(), []*Event{{Name: "a"}, {Name: "b"}})
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid log output %q: %v", buf.String(), err)
+	}
+	if record["level"] != "DEBUG" || record["batch_size"] != float64(2) || record["status_code"] != float64(200) {
+		t.Errorf("unexpected record: %v", record)
+	}
+	if _, ok := record["latency"]; !ok {
+		t.Error("record missing latency")
+	}
+	if !strings.HasSuffix(record["url"].(string), "/api/events/batch") {
+		t.Errorf("url = %v", record["url"])
+	}
+}
+
+// TestSlogHandler 测试日志记录转换为事件
+func TestSlogHandler(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp")
+
+	var buf bytes.Buffer
+	handler := NewSlogHandler(client, slog.NewTextHandler(&buf, nil), &SlogHandlerOptions{TrackAttr: "telemetry"})
+	logger := slog.New(handler).With("service", "billing").WithGroup("req")
+
+	logger.Info("ignored")
+	logger.Info("feature used", "telemetry", true, "feature", "export")
+	logger.Warn("payment declined", slog.Group("card", "brand", "visa"))
+	client.Close()
+
+	if n := strings.Count(buf.String(), "\n"); n != 3 {
+		t.Errorf("next handler received %d records, want 3", n)
+	}
+
+	events := server.Events()
+	if len(events) != 2 {
+		t.Fatalf("received %d events, want 2", len(events))
+	}
+	if events[0].Name != "log" || events[0].Properties["message"] != "feature used" ||
+		events[0].Properties["req.feature"] != "export" || events[0].Properties["service"] != "billing" {
+		t.Errorf("events[0] = %+v", events[0])
+	}
+	if events[1].Properties["level"] != "WARN" || events[1].Properties["req.card.brand"] != "visa" {
+		t.Errorf("events[1] = %+v", events[1])
+	}
+}
+
+// TestSlogHandlerSkipsInternalRecords 测试客户端自身日志不会被转换为事件
+func TestSlogHandlerSkipsInternalRecords(t *testing.T) {
+	client := NewClient("http://127.0.0.1:0", "TestApp", WithBufferSize(1))
+	defer client.Close()
+
+	handler := NewSlogHandler(client, nil, &SlogHandlerOptions{Level: slog.LevelDebug})
+	client.slogger = slog.New(handler)
+
+	// 缓冲区满时的警告日志不应再次入队
+	for i := 0; i < 10; i++ {
+		client.enqueue(&Event{Name: "fill"})
+	}
+}
@@ -0,0 +1,149 @@
+package analytics
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// =============================================================================
+// TLS 加固：自定义 CA、证书固定与双向 TLS
+// =============================================================================
+
+// WithHTTPClient 使用自定义 HTTP 客户端
+//
+// 事件上报与安装上报均使用该客户端的副本，WithTimeout 与 TLS 相关选项
+// 只修改副本，传入的实例本身不会被改动。
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		if httpClient != nil {
+			copied := *httpClient
+			c.httpClient = &copied
+		}
+	}
+}
+
+// WithTLSConfig 设置 TLS 配置
+//
+// 可用于信任私有 CA：
+//
+//	pool := x509.NewCertPool()
+//	pool.AppendCertsFromPEM(caPEM)
+//	analytics.WithTLSConfig(&tls.Config{RootCAs: pool})
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *Client) {
+		if config != nil {
+			c.tlsConfig = config.Clone()
+		}
+	}
+}
+
+// WithPinnedSPKI 固定服务端证书公钥
+//
+// hashes 为证书 SubjectPublicKeyInfo 的 SHA256 摘要（Base64 编码，
+// 可带 "sha256/" 前缀），可通过 SPKIHash 计算。只匹配校验通过的证书链中的证书，
+// 服务端额外附带的证书不参与匹配；设置 InsecureSkipVerify 时只匹配叶子证书。
+// 建议同时固定当前与备用密钥。证书固定在常规证书校验之后执行。
+func WithPinnedSPKI(hashes ...string) ClientOption {
+	return func(c *Client) {
+		for _, h := range hashes {
+			c.pinnedSPKI = append(c.pinnedSPKI, strings.TrimPrefix(strings.TrimSpace(h), "sha256/"))
+		}
+	}
+}
+
+// WithClientCertificate 设置双向 TLS 使用的客户端证书
+//
+//	cert, err := tls.LoadX509KeyPair("client.crt", "client.key")
+//	analytics.WithClientCertificate(cert)
+func WithClientCertificate(cert tls.Certificate) ClientOption {
+	return func(c *Client) {
+		c.clientCerts = append(c.clientCerts, cert)
+	}
+}
+
+// SPKIHash 计算证书 SubjectPublicKeyInfo 的 SHA256 摘要（Base64 编码）
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// applyTLSOptions 根据 TLS 相关选项构建 HTTP 客户端的传输层
+//
+// 自定义传输层不是 *http.Transport 时无法应用证书固定与双向 TLS，返回错误。
+func (c *Client) applyTLSOptions() error {
+	if c.tlsConfig == nil && len(c.pinnedSPKI) == 0 && len(c.clientCerts) == 0 {
+		return nil
+	}
+
+	var transport *http.Transport
+	switch t := c.httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return fmt.Errorf("%w: TLS options require *http.Transport, got %T", ErrInvalidConfig, t)
+	}
+
+	tlsConfig := c.tlsConfig
+	if tlsConfig == nil {
+		if transport.TLSClientConfig != nil {
+			tlsConfig = transport.TLSClientConfig.Clone()
+		} else {
+			tlsConfig = &tls.Config{}
+		}
+	}
+	if tlsConfig.MinVersion == 0 {
+		tlsConfig.MinVersion = tls.VersionTLS12
+	}
+	tlsConfig.Certificates = append(tlsConfig.Certificates, c.clientCerts...)
+
+	if len(c.pinnedSPKI) > 0 {
+		pins := make(map[string]struct{}, len(c.pinnedSPKI))
+		for _, p := range c.pinnedSPKI {
+			pins[p] = struct{}{}
+		}
+		next := tlsConfig.VerifyConnection
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			if next != nil {
+				if err := next(cs); err != nil {
+					return err
+				}
+			}
+			return verifyPinnedSPKI(cs, pins)
+		}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	// 复制 http.Client，避免修改调用方传入的实例
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+	return nil
+}
+
+// verifyPinnedSPKI 检查校验通过的证书链中是否存在已固定的公钥
+//
+// PeerCertificates 由服务端提供且未经校验，攻击者可以在自己的证书链后附带公开的
+// 已固定证书，因此只匹配 VerifiedChains；跳过校验时（没有 VerifiedChains）只匹配叶子证书。
+func verifyPinnedSPKI(cs tls.ConnectionState, pins map[string]struct{}) error {
+	if len(cs.VerifiedChains) == 0 && len(cs.PeerCertificates) > 0 {
+		if _, ok := pins[SPKIHash(cs.PeerCertificates[0])]; ok {
+			return nil
+		}
+	}
+	for _, chain := range cs.VerifiedChains {
+		for _, cert := range chain {
+			if _, ok := pins[SPKIHash(cert)]; ok {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrCertificatePinMismatch, cs.ServerName)
+}
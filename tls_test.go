@@ -0,0 +1,198 @@
+package analytics
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestTLSPinning 测试证书固定
+func TestTLSPinning(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	tlsConfig := &tls.Config{RootCAs: pool}
+
+	tests := []struct {
+		name    string
+		pins    []string
+		wantErr bool
+	}{
+		{"无固定", nil, false},
+		{"匹配的公钥", []string{"sha256/" + SPKIHash(server.Certificate())}, false},
+		{"不匹配的公钥", []string{"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(server.URL, "TestApp",
+				WithTLSConfig(tlsConfig),
+				WithPinnedSPKI(tt.pins...),
+			)
+			defer client.Close()
+
+			err := client.sendEvents(context.Background(), []*Event{{Name: "tls"}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sendEvents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrNetworkFailure) {
+				t.Errorf("sendEvents() error = %v, want ErrNetworkFailure", err)
+			}
+		})
+	}
+}
+
+// TestTLSClientCertificate 测试双向 TLS
+func TestTLSClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	// 未提供客户端证书时握手失败
+	client := NewClient(server.URL, "TestApp", WithTLSConfig(&tls.Config{RootCAs: pool}))
+	if err := client.sendInstallInfo(context.Background(), &InstallInfo{Product: "TestApp"}); err == nil {
+		t.Error("sendInstallInfo() without client certificate should fail")
+	}
+	client.Close()
+
+	client = NewClient(server.URL, "TestApp",
+		WithTLSConfig(&tls.Config{RootCAs: pool}),
+		WithClientCertificate(newTestCertificate(t)),
+	)
+	defer client.Close()
+	if err := client.sendInstallInfo(context.Background(), &InstallInfo{Product: "TestApp"}); err != nil {
+		t.Errorf("sendInstallInfo() error = %v", err)
+	}
+}
+
+// TestWithHTTPClientNotMutated 测试 TLS 选项不会修改调用方传入的 HTTP 客户端
+func TestWithHTTPClientNotMutated(t *testing.T) {
+	custom := &http.Client{Timeout: 3 * time.Second}
+	client := NewClient("https://localhost", "TestApp",
+		WithHTTPClient(custom),
+		WithPinnedSPKI("abc"),
+	)
+	defer client.Close()
+
+	if custom.Transport != nil {
+		t.Error("caller's http.Client was mutated")
+	}
+
+	// WithTimeout 在 WithHTTPClient 之后同样只修改副本
+	timed := NewClient("https://localhost", "TestApp", WithHTTPClient(custom), WithTimeout(time.Second))
+	defer timed.Close()
+	if custom.Timeout != 3*time.Second || timed.httpClient.Timeout != time.Second {
+		t.Errorf("timeouts = %v (caller), %v (client), want 3s and 1s", custom.Timeout, timed.httpClient.Timeout)
+	}
+	if client.httpClient == custom || client.httpClient.Timeout != 3*time.Second {
+		t.Error("client should use a copy of the custom http.Client")
+	}
+}
+
+// newTestCertificate 生成自签名的客户端证书
+func newTestCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "analytics-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pub, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: priv}
+}
+
+// TestTLSPinningIgnoresUnverifiedCertificates 测试服务端附带的未经校验的证书不能通过证书固定
+func TestTLSPinningIgnoresUnverifiedCertificates(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// 攻击者在自己的证书链后附带公开的已固定证书
+	pinned, err := x509.ParseCertificate(newTestCertificate(t).Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &server.TLS.Certificates[0]
+	cert.Certificate = append(cert.Certificate, pinned.Raw)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	tests := []struct {
+		name    string
+		config  *tls.Config
+		pin     string
+		wantErr bool
+	}{
+		{"附带的证书", &tls.Config{RootCAs: pool}, SPKIHash(pinned), true},
+		{"跳过校验时附带的证书", &tls.Config{InsecureSkipVerify: true}, SPKIHash(pinned), true},
+		{"跳过校验时匹配叶子证书", &tls.Config{InsecureSkipVerify: true}, SPKIHash(server.Certificate()), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(server.URL, "TestApp", WithTLSConfig(tt.config), WithPinnedSPKI(tt.pin))
+			defer client.Close()
+
+			err := client.sendEvents(context.Background(), []*Event{{Name: "tls"}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sendEvents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// roundTripperFunc 测试用的自定义传输层
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// TestTLSOptionsUnsupportedTransport 测试自定义传输层无法应用 TLS 选项时拒绝发送
+func TestTLSOptionsUnsupportedTransport(t *testing.T) {
+	called := false
+	custom := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		called = true
+		return nil, errors.New("unreachable")
+	})}
+	client := NewClient("https://localhost", "TestApp",
+		WithHTTPClient(custom),
+		WithPinnedSPKI("abc"),
+	)
+	defer client.Close()
+
+	if !errors.Is(client.Err(), ErrInvalidConfig) {
+		t.Fatalf("Err() = %v, want ErrInvalidConfig", client.Err())
+	}
+	if err := client.sendEvents(context.Background(), []*Event{{Name: "tls"}}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("sendEvents() error = %v, want ErrInvalidConfig", err)
+	}
+	if called {
+		t.Error("request was sent without the TLS options")
+	}
+}
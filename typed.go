@@ -0,0 +1,234 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 类型化事件：泛型 Track 与事件目录
+// =============================================================================
+
+// Track 发送属性为结构体的事件（异步），结构体按 json 标签序列化为事件属性
+//
+//	type Purchase struct {
+//	    SKU    string  `json:"sku"`
+//	    Amount float64 `json:"amount"`
+//	}
+//	analytics.Track(client, "purchase", Purchase{SKU: "A-1", Amount: 9.9})
+//
+// props 序列化失败或不是 JSON 对象时返回错误，事件不会发送；
+// 客户端启用 WithEventCatalog 时，事件必须已通过 DefineEvent 定义且 T 与定义的类型一致，
+// 否则返回 ErrUndefinedEvent；
+// 入队失败时返回与 TrackStrict 相同的原因（违反跟踪计划、被限流或缓冲区满）。
+func Track[T any](c *Client, name string, props T) error {
+	if c.eventCatalog {
+		if err := checkCatalog(name, reflect.TypeOf((*T)(nil)).Elem()); err != nil {
+			return newClientError("Track", err)
+		}
+	}
+	properties, err := toProperties(props)
+	if err != nil {
+		return newClientError("Track", err)
+	}
+	e := newEvent()
+	e.Name = name
+	e.Timestamp = time.Now().Unix()
+	e.Properties = properties
+	return c.enqueue(e)
+}
+
+// WithEventCatalog 将客户端绑定到事件目录
+//
+// 启用后泛型 Track 只接受通过 DefineEvent 定义的事件，且属性类型必须与定义一致
+// （允许传入指向该类型的指针），避免绕过目录发送拼错名称或结构不符的事件。
+func WithEventCatalog() ClientOption {
+	return func(c *Client) {
+		c.eventCatalog = true
+	}
+}
+
+// checkCatalog 检查事件已在目录中定义且属性类型一致
+func checkCatalog(name string, typ reflect.Type) error {
+	catalogMu.RLock()
+	info, ok := catalog[name]
+	catalogMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUndefinedEvent, name)
+	}
+	if typ != info.Type && !(typ.Kind() == reflect.Ptr && typ.Elem() == info.Type) {
+		return fmt.Errorf("%w: %q is defined with type %s, got %s", ErrUndefinedEvent, name, info.Type, typ)
+	}
+	return nil
+}
+
+// toProperties 将值序列化为事件属性
+func toProperties(v interface{}) (map[string]interface{}, error) {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMarshalFailed, err)
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var properties map[string]interface{}
+	if err := dec.Decode(&properties); err != nil {
+		return nil, fmt.Errorf("%w: properties must be a JSON object, got %s", ErrMarshalFailed, data[:1])
+	}
+	return properties, nil
+}
+
+// EventDef 绑定到一个属性结构体类型的事件定义
+//
+// 通过 DefineEvent 创建，同一事件名称只能绑定一种类型，属性名在编译期由结构体检查：
+//
+//	var PurchaseEvent = analytics.DefineEvent[Purchase]("purchase", "用户完成购买")
+//
+//	PurchaseEvent.Track(client, Purchase{SKU: "A-1", Amount: 9.9})
+type EventDef[T any] struct {
+	name string
+}
+
+// EventInfo 事件目录中的一条记录
+type EventInfo struct {
+	Name        string
+	Description string
+	Type        reflect.Type
+	Properties  []PropertyInfo
+}
+
+// PropertyInfo 事件属性说明
+type PropertyInfo struct {
+	Name     string // JSON 属性名
+	Type     string // Go 类型
+	Optional bool   // 带有 omitempty
+}
+
+var (
+	catalogMu sync.RWMutex
+	catalog   = make(map[string]*EventInfo)
+)
+
+// DefineEvent 定义事件并登记到事件目录
+//
+// 相同名称以相同类型重复定义时返回等价的定义；绑定到不同类型时 panic，
+// 便于在程序启动时发现冲突。
+func DefineEvent[T any](name, description string) *EventDef[T] {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	if info, ok := catalog[name]; ok {
+		if info.Type != typ {
+			panic(fmt.Sprintf("analytics: event %q already defined with type %s, cannot redefine with %s", name, info.Type, typ))
+		}
+		if info.Description == "" {
+			info.Description = description
+		}
+		return &EventDef[T]{name: name}
+	}
+
+	catalog[name] = &EventInfo{
+		Name:        name,
+		Description: description,
+		Type:        typ,
+		Properties:  describeProperties(typ),
+	}
+	return &EventDef[T]{name: name}
+}
+
+// Name 返回事件名称
+func (d *EventDef[T]) Name() string {
+	return d.name
+}
+
+// Track 发送事件（异步），错误与包级 Track 相同
+func (d *EventDef[T]) Track(c *Client, props T) error {
+	return Track(c, d.name, props)
+}
+
+// Catalog 返回所有已定义的事件，按名称排序
+func Catalog() []EventInfo {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	infos := make([]EventInfo, 0, len(catalog))
+	for _, info := range catalog {
+		infos = append(infos, *info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// LookupEvent 返回已定义的事件
+func LookupEvent(name string) (EventInfo, bool) {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	info, ok := catalog[name]
+	if !ok {
+		return EventInfo{}, false
+	}
+	return *info, true
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// describeProperties 按 encoding/json 的规则列出结构体的属性，非结构体返回 nil
+func describeProperties(typ reflect.Type) []PropertyInfo {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || typ == timeType || typ.Implements(marshalerType) {
+		return nil
+	}
+
+	var props []PropertyInfo
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// 匿名结构体字段的属性提升到外层，与 encoding/json 一致
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				props = append(props, describeProperties(ft)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		props = append(props, PropertyInfo{
+			Name:     name,
+			Type:     field.Type.String(),
+			Optional: strings.Contains(opts, "omitempty"),
+		})
+	}
+	return props
+}
@@ -0,0 +1,148 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type purchaseProps struct {
+	SKU      string  `json:"sku"`
+	Amount   float64 `json:"amount"`
+	Coupon   string  `json:"coupon,omitempty"`
+	internal string
+	Ignored  string `json:"-"`
+}
+
+type signupProps struct {
+	Plan string `json:"plan"`
+}
+
+// TestTrackGeneric 测试结构体按 json 标签序列化为事件属性
+func TestTrackGeneric(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp", WithFlushInterval(time.Hour))
+
+	if err := Track(client, "purchase", purchaseProps{SKU: "A-1", Amount: 9.9, internal: "x", Ignored: "y"}); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	if err := Track(client, "count", 3); err == nil {
+		t.Error("Track with a non-object value succeeded, want error")
+	}
+	client.Close()
+
+	events := server.Events()
+	if len(events) != 1 {
+		t.Fatalf("received %d events, want 1", len(events))
+	}
+	props := events[0].Properties
+	if props["sku"] != "A-1" || props["amount"] != 9.9 {
+		t.Errorf("properties = %v", props)
+	}
+	for _, key := range []string{"coupon", "internal", "Ignored"} {
+		if _, ok := props[key]; ok {
+			t.Errorf("unexpected property %q in %v", key, props)
+		}
+	}
+}
+
+// TestDefineEvent 测试事件定义与目录
+func TestDefineEvent(t *testing.T) {
+	def := DefineEvent[purchaseProps]("test_purchase", "用户完成购买")
+	if again := DefineEvent[purchaseProps]("test_purchase", ""); again.Name() != def.Name() {
+		t.Error("redefining with the same type returned a different definition")
+	}
+
+	info, ok := LookupEvent("test_purchase")
+	if !ok {
+		t.Fatal("event not found in catalog")
+	}
+	if info.Description != "用户完成购买" || len(info.Properties) != 3 {
+		t.Fatalf("catalog entry = %+v", info)
+	}
+	if p := info.Properties[2]; p.Name != "coupon" || p.Type != "string" || !p.Optional {
+		t.Errorf("coupon property = %+v", p)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("binding an event name to a second type did not panic")
+		}
+	}()
+	DefineEvent[signupProps]("test_purchase", "")
+}
+
+// TestEventDefTrack 测试通过事件定义发送
+func TestEventDefTrack(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp", WithFlushInterval(time.Hour))
+
+	signup := DefineEvent[signupProps]("test_signup", "")
+	if err := signup.Track(client, signupProps{Plan: "pro"}); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	client.Close()
+
+	events := server.Events()
+	if len(events) != 1 || events[0].Name != "test_signup" || events[0].Properties["plan"] != "pro" {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+// TestTrackGenericEnqueueErrors 测试类型化 Track 返回入队失败的原因
+func TestTrackGenericEnqueueErrors(t *testing.T) {
+	client := NewClient("http://127.0.0.1:0", "TestApp", WithFlushInterval(time.Hour),
+		WithTrackingPlan(mustPlan(t), SchemaReject), WithRateLimit(0.001, 1))
+	defer client.Close()
+
+	type purchase struct {
+		SKU string `json:"sku"`
+	}
+	var schemaErr *SchemaError
+	if err := Track(client, "purchase", purchase{SKU: "ABC-1"}); !errors.As(err, &schemaErr) {
+		t.Errorf("Track(invalid) error = %v, want *SchemaError", err)
+	}
+
+	signup := DefineEvent[signupProps]("signup", "")
+	if err := signup.Track(client, signupProps{}); err != nil {
+		t.Fatalf("first Track() error = %v", err)
+	}
+	if err := signup.Track(client, signupProps{}); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("second Track() error = %v, want ErrRateLimited", err)
+	}
+}
+
+// TestTrackGenericEventCatalog 测试绑定事件目录后只接受已定义且类型一致的事件
+func TestTrackGenericEventCatalog(t *testing.T) {
+	server := newEventCollector(t)
+	client := NewClient(server.URL, "TestApp", WithFlushInterval(time.Hour), WithEventCatalog())
+
+	DefineEvent[signupProps]("test_catalog_signup", "")
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"defined", Track(client, "test_catalog_signup", signupProps{Plan: "pro"}), nil},
+		{"pointer", Track(client, "test_catalog_signup", &signupProps{Plan: "team"}), nil},
+		{"undefined", Track(client, "test_catalog_signpu", signupProps{}), ErrUndefinedEvent},
+		{"wrong type", Track(client, "test_catalog_signup", map[string]interface{}{"plan": "pro"}), ErrUndefinedEvent},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: Track() error = %v, want %v", tt.name, tt.err, tt.want)
+		}
+	}
+	client.Close()
+
+	if events := server.Events(); len(events) != 2 {
+		t.Errorf("sent %d events, want 2", len(events))
+	}
+
+	// 未绑定目录的客户端不做检查
+	other := NewClient(server.URL, "TestApp", WithFlushInterval(time.Hour))
+	defer other.Close()
+	if err := Track(other, "test_catalog_signpu", signupProps{}); err != nil {
+		t.Errorf("Track() without catalog error = %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package analytics
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// 入队时按跟踪计划校验事件
+// =============================================================================
+
+// SchemaAction 事件违反跟踪计划时的处理方式
+type SchemaAction int
+
+const (
+	// SchemaDrop 丢弃事件，只记录到违规报告
+	SchemaDrop SchemaAction = iota
+
+	// SchemaTag 仍然发送，并在属性 SchemaErrorsProperty 中附上错误
+	SchemaTag
+
+	// SchemaReject 丢弃事件，TrackStrict 返回 *SchemaError
+	SchemaReject
+)
+
+// SchemaErrorsProperty SchemaTag 模式下记录校验错误的属性名
+const SchemaErrorsProperty = "_schema_errors"
+
+// 违规报告中记录的最大事件名称数
+const maxSchemaViolations = 1000
+
+// SchemaViolation 违规报告中一个事件名称的汇总
+type SchemaViolation struct {
+	Event      string
+	Count      int
+	LastErrors []string
+	LastSeen   time.Time
+}
+
+// schemaValidator 跟踪计划、处理方式与违规统计
+type schemaValidator struct {
+	plan   *TrackingPlan
+	action SchemaAction
+
+	mu         sync.Mutex
+	violations map[string]*SchemaViolation
+}
+
+// WithTrackingPlan 在事件入队时按跟踪计划校验属性
+//
+//	plan, err := analytics.LoadTrackingPlan("tracking-plan.json")
+//	client := analytics.NewClient(url, "MyApp", analytics.WithTrackingPlan(plan, analytics.SchemaReject))
+//
+// 校验在入队时进行，一个不合法的事件不会导致整个批次被服务端以 400 拒绝。
+func WithTrackingPlan(plan *TrackingPlan, action SchemaAction) ClientOption {
+	return func(c *Client) {
+		c.schema = &schemaValidator{
+			plan:       plan,
+			action:     action,
+			violations: make(map[string]*SchemaViolation),
+		}
+	}
+}
+
+// TrackStrict 发送事件（异步），返回事件未能入队的原因
+//
+// 可能的错误：SchemaReject 模式下违反跟踪计划（*SchemaError）、被限流（ErrRateLimited）、
+// 缓冲区已满（ErrBufferFull）。未授权或未被采样的事件属于预期内的过滤，返回 nil。
+func (c *Client) TrackStrict(eventName string, properties map[string]interface{}) error {
+	e := newEvent()
+	e.Name = eventName
+	e.Timestamp = time.Now().Unix()
+	e.Properties = properties
+	return c.enqueue(e)
+}
+
+// SchemaViolations 返回违规报告，按违规次数从多到少排列
+func (c *Client) SchemaViolations() []SchemaViolation {
+	if c.schema == nil {
+		return nil
+	}
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+
+	report := make([]SchemaViolation, 0, len(c.schema.violations))
+	for _, v := range c.schema.violations {
+		report = append(report, *v)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Count != report[j].Count {
+			return report[i].Count > report[j].Count
+		}
+		return report[i].Event < report[j].Event
+	})
+	return report
+}
+
+// checkSchema 校验事件，返回是否丢弃以及需要返回给 TrackStrict 的错误
+func (c *Client) checkSchema(event *Event) (bool, error) {
+	if c.schema == nil || c.schema.plan == nil {
+		return false, nil
+	}
+	err := c.schema.plan.Validate(event.Name, event.Properties)
+	if err == nil {
+		return false, nil
+	}
+	schemaErr := err.(*SchemaError)
+	c.schema.record(schemaErr)
+
+	if c.debug && c.logger != nil {
+		c.logger.Printf("[Analytics] %v", schemaErr)
+	}
+	c.logAttrs(slog.LevelWarn, "analytics: event violates tracking plan",
+		slog.String("event", event.Name), slog.Any("errors", schemaErr.Errors))
+
+	switch c.schema.action {
+	case SchemaTag:
+		// 复制属性，不修改调用方的 map
+		props := make(map[string]interface{}, len(event.Properties)+1)
+		for k, v := range event.Properties {
+			props[k] = v
+		}
+		props[SchemaErrorsProperty] = schemaErr.Errors
+		event.Properties = props
+		return false, nil
+	case SchemaReject:
+		return true, schemaErr
+	default:
+		return true, nil
+	}
+}
+
+// record 记录一次违规
+func (v *schemaValidator) record(err *SchemaError) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	entry, ok := v.violations[err.Event]
+	if !ok {
+		if len(v.violations) >= maxSchemaViolations {
+			return
+		}
+		entry = &SchemaViolation{Event: err.Event}
+		v.violations[err.Event] = entry
+	}
+	entry.Count++
+	entry.LastErrors = err.Errors
+	entry.LastSeen = time.Now()
+}